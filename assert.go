@@ -0,0 +1,38 @@
+package logg
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+//TestingT the part of *testing.T used by AssertLogged
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+var assertRecorders int64
+
+//AssertLogged attach a temporary appender to log, run fn and report an error
+//to t unless a message at level containing substring was written meanwhile.
+//The substring is searched in the text output without timestamp, like
+//`[I][file.go:12] msg key=value`
+func AssertLogged(t TestingT, log *BaseLogger, level int, substring string, fn func()) bool {
+	t.Helper()
+	r := NewRecorder()
+	name := "logg.assert#" + strconv.FormatInt(atomic.AddInt64(&assertRecorders, 1), 10)
+	log.AddAppender(name, r)
+	fn()
+	log.Flush()
+	log.RemoveAppender(name)
+	r.Lock()
+	defer r.Unlock()
+	for i, text := range r.msgs {
+		if r.levels[i] == level && strings.Contains(text, substring) {
+			return true
+		}
+	}
+	t.Errorf("logg: no %s message containing %q among %d messages: %q", levelNames[level], substring, len(r.msgs), r.msgs)
+	return false
+}
@@ -0,0 +1,41 @@
+package logg
+
+import (
+	"fmt"
+	"testing"
+)
+
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertLogged(t *testing.T) {
+	log := NewLogger(10)
+	log.Async()
+	defer log.Close()
+	ft := &fakeT{}
+	if !AssertLogged(ft, log, LevelWarn, "disk full", func() { log.Warn("disk full on %s", "/var") }) {
+		t.Fatalf("present message not found: %q", ft.errors)
+	}
+	if len(ft.errors) != 0 {
+		t.Fatalf("unexpected errors %q", ft.errors)
+	}
+	if AssertLogged(ft, log, LevelError, "disk full", func() { log.Warn("disk full") }) {
+		t.Fatal("a message at another level should not match")
+	}
+	if AssertLogged(ft, log, LevelInfo, "started", func() {}) {
+		t.Fatal("an absent message should not match")
+	}
+	if len(ft.errors) != 2 {
+		t.Fatalf("got errors %q, want 2", ft.errors)
+	}
+	if len(log.HealthCheck()) != 0 {
+		t.Fatal("the temporary appenders should be removed")
+	}
+}
@@ -0,0 +1,43 @@
+package logg
+
+import "time"
+
+//FatalAt log.Fatal with when as the message time, to replay or import past events
+func (log *BaseLogger) FatalAt(when time.Time, format string, v ...interface{}) {
+	if log.skipped(LevelFatal) {
+		return
+	}
+	log.output(0, when, LevelFatal, sprintf(format, v...), nil)
+}
+
+//ErrorAt log.Error with when as the message time, to replay or import past events
+func (log *BaseLogger) ErrorAt(when time.Time, format string, v ...interface{}) {
+	if log.skipped(LevelError) {
+		return
+	}
+	log.output(0, when, LevelError, sprintf(format, v...), nil)
+}
+
+//WarnAt log.Warn with when as the message time, to replay or import past events
+func (log *BaseLogger) WarnAt(when time.Time, format string, v ...interface{}) {
+	if log.skipped(LevelWarn) {
+		return
+	}
+	log.output(0, when, LevelWarn, sprintf(format, v...), nil)
+}
+
+//InfoAt log.Info with when as the message time, to replay or import past events
+func (log *BaseLogger) InfoAt(when time.Time, format string, v ...interface{}) {
+	if log.skipped(LevelInfo) {
+		return
+	}
+	log.output(0, when, LevelInfo, sprintf(format, v...), nil)
+}
+
+//DebugAt log.Debug with when as the message time, to replay or import past events
+func (log *BaseLogger) DebugAt(when time.Time, format string, v ...interface{}) {
+	if log.skipped(LevelDebug) {
+		return
+	}
+	log.output(0, when, LevelDebug, sprintf(format, v...), nil)
+}
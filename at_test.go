@@ -0,0 +1,30 @@
+package logg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogAt(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "replay.log")
+	log := NewLogger(10)
+	log.EnableFuncCallDepath(true)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`"}`); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Date(2019, 3, 4, 5, 6, 7, 0, time.Local)
+	log.InfoAt(past, "event %d", 1)
+	log.ErrorAt(past.Add(time.Hour), "event %d", 2)
+	log.Close()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "2019-03-04 05:06:07.000000[I][at_test.go:18] event 1\n" +
+		"2019-03-04 06:06:07.000000[E][at_test.go:19] event 2\n"
+	if string(data) != want {
+		t.Fatalf("got %q\nwant %q", data, want)
+	}
+}
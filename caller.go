@@ -0,0 +1,75 @@
+package logg
+
+import (
+	"path"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+//CallerFormat how the caller file is rendered, see SetCallerFormat
+type CallerFormat int
+
+const (
+	//CallerShort the file name only, like "handler.go:42", the default
+	CallerShort CallerFormat = iota
+	//CallerFull the absolute path of the file as it was built
+	CallerFull
+	//CallerPkg the package path inside the main module and the file name,
+	//like "pkg/sub/handler.go:42", the whole import path for other modules
+	CallerPkg
+)
+
+//SetCallerFormat choose how the caller enabled by EnableFuncCallDepath is
+//rendered, base file names of different packages collide with CallerShort
+func (log *BaseLogger) SetCallerFormat(format CallerFormat) {
+	log.callerFormat = format
+}
+
+var (
+	mainModuleOnce sync.Once
+	mainModule     string
+)
+
+//mainModulePath the module path of the running program, empty when unknown
+func mainModulePath() string {
+	mainModuleOnce.Do(func() {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			mainModule = info.Main.Path
+		}
+	})
+	return mainModule
+}
+
+//callerFile render file, the caller at pc, with format
+func callerFile(format CallerFormat, pc uintptr, file string) string {
+	switch format {
+	case CallerFull:
+		return file
+	case CallerPkg:
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			return pkgFile(fn.Name(), file, mainModulePath())
+		}
+	}
+	_, filename := path.Split(file)
+	return filename
+}
+
+//pkgFile join the package of the function named function, relative to
+//module when inside it, and the base name of file
+func pkgFile(function string, file string, module string) string {
+	_, filename := path.Split(file)
+	pkg := function
+	slash := strings.LastIndexByte(pkg, '/')
+	if dot := strings.IndexByte(pkg[slash+1:], '.'); dot >= 0 {
+		pkg = pkg[:slash+1+dot]
+	}
+	if len(module) > 0 {
+		if pkg == module {
+			return filename
+		}
+		pkg = strings.TrimPrefix(pkg, module+"/")
+	}
+	return pkg + "/" + filename
+}
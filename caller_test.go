@@ -0,0 +1,51 @@
+package logg
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestSetCallerFormat(t *testing.T) {
+	log := NewLogger(10)
+	log.EnableFuncCallDepath(true)
+	r := addRecorder(log, "record")
+	log.Info("short")
+	log.SetCallerFormat(CallerFull)
+	log.Info("full")
+	log.SetCallerFormat(CallerPkg)
+	log.Info("pkg")
+	lines := r.Lines()
+	if len(lines) != 3 {
+		t.Fatalf("got %q", lines)
+	}
+	if !regexp.MustCompile(`^\[I\]\[caller_test\.go:\d+\] short$`).MatchString(lines[0]) {
+		t.Fatalf("short got %q", lines[0])
+	}
+	full := regexp.MustCompile(`^\[I\]\[(.+)/caller_test\.go:\d+\] full$`).FindStringSubmatch(lines[1])
+	if full == nil || !filepath.IsAbs(full[1]) {
+		t.Fatalf("full got %q", lines[1])
+	}
+	//the test is in the module root, its package path is empty
+	if !regexp.MustCompile(`^\[I\]\[(github\.com/colefan/logg/)?caller_test\.go:\d+\] pkg$`).MatchString(lines[2]) {
+		t.Fatalf("pkg got %q", lines[2])
+	}
+}
+
+func TestPkgFile(t *testing.T) {
+	module := "example.com/app"
+	for _, c := range []struct{ function, want string }{
+		{"example.com/app/pkg/sub.(*Handler).ServeHTTP", "pkg/sub/handler.go"},
+		{"example.com/app/pkg/sub.Func.func1", "pkg/sub/handler.go"},
+		{"example.com/app.main", "handler.go"},
+		{"github.com/other/lib.Call", "github.com/other/lib/handler.go"},
+		{"main.main", "main/handler.go"},
+	} {
+		if got := pkgFile(c.function, "/src/x/handler.go", module); got != c.want {
+			t.Errorf("pkgFile(%q) = %q, want %q", c.function, got, c.want)
+		}
+	}
+	if got := pkgFile("example.com/app/pkg.F", "/src/pkg/f.go", ""); got != "example.com/app/pkg/f.go" {
+		t.Errorf("without module got %q", got)
+	}
+}
@@ -0,0 +1,83 @@
+package logg
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//circuitAppender stop writing to inner for a while once it keeps failing
+type circuitAppender struct {
+	sync.Mutex
+	inner     Appender
+	threshold int
+	cooldown  time.Duration
+	failures  int       //consecutive failures
+	openUntil time.Time //messages are dropped until then
+	now       func() time.Time
+}
+
+//NewCircuitAppender return an appender writing to inner until it fails
+//failureThreshold times in a row. The messages of the next cooldown are then
+//dropped without calling inner, after which one message probes it: a success
+//closes the circuit, a failure opens it for another cooldown. Only the error
+//opening the circuit reaches the logger's error handler. inner must already
+//be initialized
+func NewCircuitAppender(inner Appender, failureThreshold int, cooldown time.Duration) Appender {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &circuitAppender{inner: inner, threshold: failureThreshold, cooldown: cooldown, now: time.Now}
+}
+
+func (c *circuitAppender) Init(config string) error {
+	return nil
+}
+
+func (c *circuitAppender) WriteMsg(when time.Time, msg string, level int) error {
+	return c.call(func() error { return c.inner.WriteMsg(when, msg, level) })
+}
+
+func (c *circuitAppender) WriteEntry(e *Entry) error {
+	if ea, ok := c.inner.(EntryAppender); ok {
+		return c.call(func() error { return ea.WriteEntry(e) })
+	}
+	text := e.Text()
+	return c.call(func() error { return c.inner.WriteMsg(e.When, text, e.Level) })
+}
+
+func (c *circuitAppender) includesGoroutine() bool {
+	return wantsGoroutine(c.inner)
+}
+
+func (c *circuitAppender) call(write func() error) error {
+	c.Lock()
+	defer c.Unlock()
+	if c.failures >= c.threshold && c.now().Before(c.openUntil) {
+		return nil
+	}
+	err := write()
+	if err == nil {
+		c.failures = 0
+		return nil
+	}
+	c.failures++
+	if c.failures < c.threshold {
+		return err
+	}
+	probe := c.failures > c.threshold
+	c.openUntil = c.now().Add(c.cooldown)
+	if probe {
+		return nil
+	}
+	return errors.New("logg: circuit open for " + c.cooldown.String() + " after " + strconv.Itoa(c.failures) + " failures: " + err.Error())
+}
+
+func (c *circuitAppender) Flush() {
+	c.inner.Flush()
+}
+
+func (c *circuitAppender) Destroy() {
+	c.inner.Destroy()
+}
@@ -0,0 +1,42 @@
+package logg
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCircuitAppender(t *testing.T) {
+	log := NewLogger(10)
+	flaky := &flakyAppender{failures: 100}
+	c := NewCircuitAppender(flaky, 3, time.Minute).(*circuitAppender)
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	log.AddAppender("circuit", c)
+	var errs []error
+	log.SetErrorHandler(func(name string, err error) { errs = append(errs, err) })
+	for i := 0; i < 10; i++ {
+		log.Info("down")
+	}
+	if flaky.calls != 3 {
+		t.Fatalf("inner called %d times while open", flaky.calls)
+	}
+	if len(errs) != 3 || !strings.Contains(errs[2].Error(), "circuit open for 1m0s after 3 failures: flaky") {
+		t.Fatalf("got errors %v", errs)
+	}
+
+	now = now.Add(time.Minute)
+	log.Info("probe")
+	log.Info("still open")
+	if flaky.calls != 4 || len(errs) != 3 {
+		t.Fatalf("failed probe: %d calls, errors %v", flaky.calls, errs)
+	}
+
+	flaky.failures = 0
+	now = now.Add(time.Minute)
+	log.Info("back")
+	log.Info("closed")
+	if lines := flaky.Lines(); len(lines) != 2 || !strings.HasSuffix(lines[1], "closed") || flaky.calls != 6 {
+		t.Fatalf("got %q after %d calls", lines, flaky.calls)
+	}
+}
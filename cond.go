@@ -0,0 +1,51 @@
+package logg
+
+//LogIf log at level only when cond holds, the message is not formatted
+//otherwise. The arguments are still evaluated by the caller, guard expensive
+//ones with an if
+func (log *BaseLogger) LogIf(cond bool, level int, format string, v ...interface{}) {
+	if !cond || level < LevelFatal || level > LevelDebug || log.skipped(level) {
+		return
+	}
+	log.writeMsg(level, sprintf(format, v...), nil)
+}
+
+//FatalIf log.Fatal when cond holds, see LogIf
+func (log *BaseLogger) FatalIf(cond bool, format string, v ...interface{}) {
+	if !cond || log.skipped(LevelFatal) {
+		return
+	}
+	log.writeMsg(LevelFatal, sprintf(format, v...), nil)
+}
+
+//ErrorIf log.Error when cond holds, see LogIf
+func (log *BaseLogger) ErrorIf(cond bool, format string, v ...interface{}) {
+	if !cond || log.skipped(LevelError) {
+		return
+	}
+	log.writeMsg(LevelError, sprintf(format, v...), nil)
+}
+
+//WarnIf log.Warn when cond holds, see LogIf
+func (log *BaseLogger) WarnIf(cond bool, format string, v ...interface{}) {
+	if !cond || log.skipped(LevelWarn) {
+		return
+	}
+	log.writeMsg(LevelWarn, sprintf(format, v...), nil)
+}
+
+//InfoIf log.Info when cond holds, see LogIf
+func (log *BaseLogger) InfoIf(cond bool, format string, v ...interface{}) {
+	if !cond || log.skipped(LevelInfo) {
+		return
+	}
+	log.writeMsg(LevelInfo, sprintf(format, v...), nil)
+}
+
+//DebugIf log.Debug when cond holds, see LogIf
+func (log *BaseLogger) DebugIf(cond bool, format string, v ...interface{}) {
+	if !cond || log.skipped(LevelDebug) {
+		return
+	}
+	log.writeMsg(LevelDebug, sprintf(format, v...), nil)
+}
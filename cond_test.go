@@ -0,0 +1,30 @@
+package logg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLogIf(t *testing.T) {
+	log := NewLogger(10)
+	log.EnableFuncCallDepath(true)
+	r := addRecorder(log, "record")
+	log.InfoIf(false, "hidden %d", 1)
+	log.LogIf(false, LevelError, "hidden")
+	log.InfoIf(true, "shown %d", 2)
+	log.LogIf(true, LevelWarn, "shown %d", 3)
+	log.LogIf(true, LevelDebug+1, "out of range")
+	lines := r.Lines()
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "[I][cond_test.go:") || !strings.HasSuffix(lines[0], "shown 2") ||
+		!strings.HasPrefix(lines[1], "[W]") || !strings.HasSuffix(lines[1], "shown 3") {
+		t.Fatalf("got %q", lines)
+	}
+	log.SetLevel(LevelDebug + 1)
+	log.LogIf(true, LevelDebug+1, "out of range")
+	if n := len(r.Lines()); n != 2 {
+		t.Fatalf("out of range level logged, %d lines", n)
+	}
+	if n := testing.AllocsPerRun(100, func() { log.DebugIf(false, "hidden %s", "arg") }); n != 0 {
+		t.Fatalf("%v allocations when the condition is false", n)
+	}
+}
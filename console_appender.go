@@ -2,8 +2,10 @@ package logg
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 )
 
@@ -25,10 +27,83 @@ var colors = []brush{
 	newBrush("1;34"), //LevelDebug
 }
 
+//labelBrush color only the level label starting the text
+func labelBrush(level int) brush {
+	label := levelLabels[level]
+	colored := colors[level](label)
+	return func(text string) string {
+		if strings.HasPrefix(text, label) {
+			return colored + text[len(label):]
+		}
+		return text
+	}
+}
+
+var labelColors = []brush{
+	labelBrush(LevelFatal),
+	labelBrush(LevelError),
+	labelBrush(LevelWarn),
+	labelBrush(LevelInfo),
+	labelBrush(LevelDebug),
+}
+
+//partsBrush color the level label with label and the rest of the text with
+//msg, a nil brush leaves its part as it is
+func partsBrush(level int, label brush, msg brush) brush {
+	plain := levelLabels[level]
+	colored := plain
+	if label != nil {
+		colored = label(plain)
+	}
+	return func(text string) string {
+		head, rest := "", text
+		if strings.HasPrefix(text, plain) {
+			head, rest = colored, text[len(plain):]
+		}
+		if msg != nil && len(rest) > 0 {
+			rest = msg(rest)
+		}
+		return head + rest
+	}
+}
+
+//validColor an SGR parameter list like "1;31"
+func validColor(color string) bool {
+	if len(color) == 0 {
+		return false
+	}
+	for _, c := range color {
+		if (c < '0' || c > '9') && c != ';' {
+			return false
+		}
+	}
+	return true
+}
+
 type consoleWriter struct {
 	lg       *logWriter
-	Level    int  `json:"level"`
-	Colorful bool `json:"color"`
+	Level    int    `json:"level"`
+	MaxLevel int    `json:"maxlevel"` //most severe level written, LevelFatal by default
+	Colorful bool   `json:"color"`
+	Scope    string `json:"colorscope"` //line (default): all but the timestamp, label: the level label only
+	Format   string `json:"format"`
+	Target   string `json:"target"` //stdout (default) or stderr
+	Layout   string `json:"timelayout"`
+	NoTime   bool   `json:"notime"` //no timestamp, for command line tools
+	//sddaemon starts each line with the <N> syslog priority systemd reads on
+	//stdout, see sd-daemon(3), length writes each line behind its big-endian
+	//uint32 length instead of ending it
+	Framing string `json:"framing"`
+	//SGR colors like "2" or "1;31" of the timestamp, the level label by level
+	//name and the rest of the text, they replace colorscope when set
+	TimeColor  string            `json:"timecolor"`
+	LabelColor map[string]string `json:"labelcolor"`
+	MsgColor   string            `json:"msgcolor"`
+	parts      []brush
+	tagOptions
+	lineWrap
+	formatOptions
+	formatter Formatter
 }
 
 //NewConsoleAppender create a console appender
@@ -41,7 +116,9 @@ func newConsoleAppender() Appender {
 	return w
 }
 
-//Init config like `{"level":1}`
+//Init config like `{"level":1,"target":"stderr","colorscope":"label","notime":true,"lineending":"crlf"}`
+//or `{"timecolor":"2","labelcolor":{"error":"1;31"},"msgcolor":"1"}`
+//or `{"notime":true,"framing":"sddaemon"}`
 func (c *consoleWriter) Init(config string) error {
 	if len(config) == 0 {
 		return nil
@@ -50,20 +127,120 @@ func (c *consoleWriter) Init(config string) error {
 	if runtime.GOOS == "windows" {
 		c.Colorful = false
 	}
+	if err != nil {
+		return err
+	}
+	switch c.Target {
+	case "", "stdout":
+	case "stderr":
+		c.lg = newLogWriter(os.Stderr)
+	default:
+		return errors.New("logg: unknow console target " + c.Target)
+	}
+	switch c.Scope {
+	case "", "line", "label":
+	default:
+		return errors.New("logg: unknow console colorscope " + c.Scope)
+	}
+	switch c.Framing {
+	case "", "sddaemon", "length":
+	case "sdnotify":
+		c.Framing = "sddaemon"
+	default:
+		return errors.New("logg: unknow console framing " + c.Framing)
+	}
+	c.lg.framing = c.Framing
+	if err := c.initColors(); err != nil {
+		return err
+	}
+	if err := c.lineWrap.init(); err != nil {
+		return err
+	}
+	c.lg.wrap = c.lineWrap
+	c.lg.noTime = c.NoTime
+	if len(c.Layout) > 0 {
+		c.lg.layout = c.Layout
+	}
+	c.formatter, err = newFormatter(c.Format, c.formatOptions)
 	return err
 }
 
 func (c *consoleWriter) WriteMsg(when time.Time, msg string, level int) error {
-	if level > c.Level {
+	if level > c.Level || level < c.MaxLevel {
 		return nil
 	}
-	if c.Colorful {
-		msg = colors[level](msg)
+	msg = c.tagText(msg)
+	if paint := c.brush(level); paint != nil {
+		msg = paint(msg)
 	}
-	c.lg.println(when, msg)
+	c.lg.println(when, msg, level)
 	return nil
 }
 
+//WriteEntry render e with the configured format, formatted output is not colored
+func (c *consoleWriter) WriteEntry(e *Entry) error {
+	if e.Level > c.Level || e.Level < c.MaxLevel {
+		return nil
+	}
+	e = c.tag(e)
+	if c.formatter == nil {
+		c.lg.printEntry(e, c.brush(e.Level))
+		return nil
+	}
+	c.lg.writeln(c.formatter.Format(e), e.Level)
+	return nil
+}
+
+//initColors build the brushes of timecolor, labelcolor and msgcolor, the
+//labels not in labelcolor keep their level color
+func (c *consoleWriter) initColors() error {
+	c.parts = nil
+	c.lg.timePaint = nil
+	if len(c.TimeColor) == 0 && len(c.LabelColor) == 0 && len(c.MsgColor) == 0 {
+		return nil
+	}
+	for _, color := range []string{c.TimeColor, c.MsgColor} {
+		if len(color) > 0 && !validColor(color) {
+			return errors.New("logg: bad console color " + color)
+		}
+	}
+	labels := append([]brush(nil), colors...)
+	for name, color := range c.LabelColor {
+		level, ok := levelStrMaps[name]
+		if !ok {
+			return errors.New("logg: unknow level " + name + " in labelcolor")
+		}
+		if !validColor(color) {
+			return errors.New("logg: bad console color " + color)
+		}
+		labels[level] = newBrush(color)
+	}
+	var msg brush
+	if len(c.MsgColor) > 0 {
+		msg = newBrush(c.MsgColor)
+	}
+	if len(c.TimeColor) > 0 && c.Colorful {
+		c.lg.timePaint = newBrush(c.TimeColor)
+	}
+	for level := range labels {
+		c.parts = append(c.parts, partsBrush(level, labels[level], msg))
+	}
+	return nil
+}
+
+func (c *consoleWriter) brush(level int) brush {
+	if !c.Colorful {
+		return nil
+	}
+	if c.parts != nil {
+		return c.parts[level]
+	}
+	if c.Scope == "label" {
+		return labelColors[level]
+	}
+	return colors[level]
+}
+
 func (c *consoleWriter) Flush() {
 
 }
@@ -1,7 +1,11 @@
 package logg
 
 import (
+	"io"
+	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestConsoleAppender(t *testing.T) {
@@ -17,3 +21,143 @@ func TestConsoleAppender(t *testing.T) {
 	log.Flush()
 	log.Close()
 }
+
+func TestConsoleAppenderStderr(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	c := newConsoleAppender()
+	err = c.Init(`{"level":4,"color":false,"target":"stderr"}`)
+	os.Stderr = stderr
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.WriteMsg(time.Now(), "[I] to stderr", LevelInfo)
+	w.Close()
+	data, _ := io.ReadAll(r)
+	if !strings.HasSuffix(string(data), "[I] to stderr\n") {
+		t.Fatalf("stderr got %q", data)
+	}
+	if err := newConsoleAppender().Init(`{"target":"printer"}`); err == nil {
+		t.Fatal("unknown target must fail")
+	}
+}
+
+func TestConsoleColorScope(t *testing.T) {
+	var buf strings.Builder
+	c := newConsoleAppender().(*consoleWriter)
+	if err := c.Init(`{"colorscope":"label"}`); err != nil {
+		t.Fatal(err)
+	}
+	c.Colorful = true
+	c.lg = newLogWriter(&buf)
+	when := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	c.WriteEntry(&Entry{When: when, Level: LevelError, Msg: "entry"})
+	c.WriteMsg(when, "[E] text", LevelError)
+	want := "2020-01-02 03:04:05.000000\033[1;31m[E]\033[0m entry\n" +
+		"2020-01-02 03:04:05.000000\033[1;31m[E]\033[0m text\n"
+	if buf.String() != want {
+		t.Fatalf("label scope got %q\nwant %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	c.Scope = "line"
+	c.WriteEntry(&Entry{When: when, Level: LevelError, Msg: "entry"})
+	if want := "2020-01-02 03:04:05.000000\033[1;31m[E] entry\033[0m\n"; buf.String() != want {
+		t.Fatalf("line scope got %q\nwant %q", buf.String(), want)
+	}
+	if err := newConsoleAppender().Init(`{"colorscope":"word"}`); err == nil {
+		t.Fatal("unknown colorscope must fail")
+	}
+}
+
+func TestConsolePartColors(t *testing.T) {
+	var buf strings.Builder
+	c := newConsoleAppender().(*consoleWriter)
+	c.lg = newLogWriter(&buf)
+	c.Colorful = true
+	if err := c.Init(`{"timecolor":"2"}`); err != nil {
+		t.Fatal(err)
+	}
+	when := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	c.WriteEntry(&Entry{When: when, Level: LevelInfo, Msg: "entry"})
+	if want := "\033[2m2020-01-02 03:04:05.000000\033[0m\033[1;34m[I]\033[0m entry\n"; buf.String() != want {
+		t.Fatalf("dim time got %q\nwant %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	c = newConsoleAppender().(*consoleWriter)
+	c.lg = newLogWriter(&buf)
+	c.Colorful = true
+	if err := c.Init(`{"labelcolor":{"warn":"7"},"msgcolor":"1"}`); err != nil {
+		t.Fatal(err)
+	}
+	c.WriteMsg(when, "[W] text", LevelWarn)
+	if want := "2020-01-02 03:04:05.000000\033[7m[W]\033[0m\033[1m text\033[0m\n"; buf.String() != want {
+		t.Fatalf("label and msg got %q\nwant %q", buf.String(), want)
+	}
+	for _, config := range []string{`{"timecolor":"red"}`, `{"labelcolor":{"trace":"1"}}`} {
+		if err := newConsoleAppender().Init(config); err == nil {
+			t.Fatalf("%s must fail", config)
+		}
+	}
+}
+
+func TestConsoleNoTime(t *testing.T) {
+	var buf strings.Builder
+	c := newConsoleAppender().(*consoleWriter)
+	c.lg = newLogWriter(&buf)
+	if err := c.Init(`{"color":false,"notime":true}`); err != nil {
+		t.Fatal(err)
+	}
+	when := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	c.WriteEntry(&Entry{When: when, Level: LevelInfo, Msg: "entry"})
+	c.WriteMsg(when, "[W] text", LevelWarn)
+	if want := "[I] entry\n[W] text\n"; buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestConsoleLineEnding(t *testing.T) {
+	var buf strings.Builder
+	c := newConsoleAppender().(*consoleWriter)
+	c.lg = newLogWriter(&buf)
+	if err := c.Init(`{"color":false,"notime":true,"lineending":"crlf"}`); err != nil {
+		t.Fatal(err)
+	}
+	c.WriteMsg(time.Now(), "[I] text", LevelInfo)
+	if buf.String() != "[I] text\r\n" {
+		t.Fatalf("got %q", buf.String())
+	}
+}
+
+func TestConsoleFraming(t *testing.T) {
+	var buf strings.Builder
+	c := newConsoleAppender().(*consoleWriter)
+	c.lg = newLogWriter(&buf)
+	if err := c.Init(`{"color":false,"notime":true,"framing":"sdnotify"}`); err != nil {
+		t.Fatal(err)
+	}
+	c.WriteMsg(time.Now(), "[W] text", LevelWarn)
+	c.WriteEntry(&Entry{Level: LevelError, Msg: "entry"})
+	if want := "<4>[W] text\n<3>[E] entry\n"; buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	c = newConsoleAppender().(*consoleWriter)
+	c.lg = newLogWriter(&buf)
+	if err := c.Init(`{"color":false,"notime":true,"framing":"length"}`); err != nil {
+		t.Fatal(err)
+	}
+	c.WriteMsg(time.Now(), "[I] text", LevelInfo)
+	if want := "\x00\x00\x00\x08[I] text"; buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+	if err := c.Init(`{"framing":"xml"}`); err == nil {
+		t.Fatal("want error for unknown framing")
+	}
+}
@@ -0,0 +1,101 @@
+package logg
+
+import "context"
+
+//ContextExtractor return the fields carried by ctx, e.g. a request or trace id
+type ContextExtractor func(ctx context.Context) []Field
+
+//AddContextExtractor add fn to the extractors used by the *Context methods
+func (log *BaseLogger) AddContextExtractor(fn ContextExtractor) {
+	log.lock.Lock()
+	log.extractors = append(log.extractors, fn)
+	log.lock.Unlock()
+}
+
+//AsyncContext is Async closing the logger, after writing and flushing the
+//pending messages, once ctx is done. Close may still be called before
+func (log *BaseLogger) AsyncContext(ctx context.Context) *BaseLogger {
+	log.Async()
+	go func() {
+		select {
+		case <-ctx.Done():
+			log.Close()
+		case <-log.done:
+		}
+	}()
+	return log
+}
+
+//levelKey the context key of WithLevel
+type levelKey struct{}
+
+//WithLevel return a copy of ctx whose *Context messages are filtered by
+//level instead of the logger level, e.g. to debug a single request. The
+//appender levels still apply
+func WithLevel(ctx context.Context, level int) context.Context {
+	return context.WithValue(ctx, levelKey{}, level)
+}
+
+//contextLevel the level of WithLevel in ctx, else the logger level
+func (log *BaseLogger) contextLevel(ctx context.Context) int {
+	if ctx != nil {
+		if level, ok := ctx.Value(levelKey{}).(int); ok {
+			return level
+		}
+	}
+	return log.Level()
+}
+
+func (log *BaseLogger) contextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	log.lock.RLock()
+	extractors := log.extractors
+	log.lock.RUnlock()
+	var fields []Field
+	for _, fn := range extractors {
+		fields = append(fields, fn(ctx)...)
+	}
+	return fields
+}
+
+//FatalContext log.Fatal with the fields extracted from ctx
+func (log *BaseLogger) FatalContext(ctx context.Context, format string, v ...interface{}) {
+	if log.skippedAt(LevelFatal, log.contextLevel(ctx)) {
+		return
+	}
+	log.writeMsg(LevelFatal, sprintf(format, v...), log.contextFields(ctx))
+}
+
+//ErrorContext log.Error with the fields extracted from ctx
+func (log *BaseLogger) ErrorContext(ctx context.Context, format string, v ...interface{}) {
+	if log.skippedAt(LevelError, log.contextLevel(ctx)) {
+		return
+	}
+	log.writeMsg(LevelError, sprintf(format, v...), log.contextFields(ctx))
+}
+
+//WarnContext log.Warn with the fields extracted from ctx
+func (log *BaseLogger) WarnContext(ctx context.Context, format string, v ...interface{}) {
+	if log.skippedAt(LevelWarn, log.contextLevel(ctx)) {
+		return
+	}
+	log.writeMsg(LevelWarn, sprintf(format, v...), log.contextFields(ctx))
+}
+
+//InfoContext log.Info with the fields extracted from ctx
+func (log *BaseLogger) InfoContext(ctx context.Context, format string, v ...interface{}) {
+	if log.skippedAt(LevelInfo, log.contextLevel(ctx)) {
+		return
+	}
+	log.writeMsg(LevelInfo, sprintf(format, v...), log.contextFields(ctx))
+}
+
+//DebugContext log.Debug with the fields extracted from ctx
+func (log *BaseLogger) DebugContext(ctx context.Context, format string, v ...interface{}) {
+	if log.skippedAt(LevelDebug, log.contextLevel(ctx)) {
+		return
+	}
+	log.writeMsg(LevelDebug, sprintf(format, v...), log.contextFields(ctx))
+}
@@ -0,0 +1,53 @@
+package logg
+
+import "sync"
+
+//std the package level logger of Default
+var std struct {
+	sync.Mutex
+	log *BaseLogger
+}
+
+//Default return the package level logger, a console logger of every level
+//created on first use unless SetDefault was called
+func Default() *BaseLogger {
+	std.Lock()
+	defer std.Unlock()
+	if std.log == nil {
+		std.log = NewLogger(defautChannelBuffer)
+		if err := std.log.SetAppender("console", `{"level":4}`); err != nil {
+			panic("logg: Default " + err.Error())
+		}
+	}
+	return std.log
+}
+
+//SetDefault replace the logger returned by Default, the previous one is not
+//closed
+func SetDefault(log *BaseLogger) {
+	std.Lock()
+	std.log = log
+	std.Unlock()
+}
+
+//Flush flush the Default logger, nothing is done if it was never used
+func Flush() {
+	std.Lock()
+	log := std.log
+	std.Unlock()
+	if log != nil {
+		log.Flush()
+	}
+}
+
+//Close close the Default logger, typically deferred in main. Nothing is done
+//if it was never used, a later Default creates a new one
+func Close() {
+	std.Lock()
+	log := std.log
+	std.log = nil
+	std.Unlock()
+	if log != nil {
+		log.Close()
+	}
+}
@@ -0,0 +1,31 @@
+package logg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultFlushClose(t *testing.T) {
+	SetDefault(nil)
+	Flush()
+	Close()
+
+	log := NewLogger(10)
+	log.Async()
+	rec := addRecorder(log, "default")
+	SetDefault(log)
+	defer SetDefault(nil)
+	Default().Info("via default")
+	Flush()
+	if lines := rec.Lines(); len(lines) != 1 || !strings.Contains(lines[0], "via default") {
+		t.Fatalf("after Flush got %q", lines)
+	}
+	Default().Warn("before close")
+	Close()
+	if lines := rec.Lines(); len(lines) != 2 {
+		t.Fatalf("after Close got %q", lines)
+	}
+	if Default() == log {
+		t.Fatal("Default kept the closed logger")
+	}
+}
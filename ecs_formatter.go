@@ -0,0 +1,106 @@
+package logg
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+//ecsVersion the ECS release the output of ECSFormatter follows
+const ecsVersion = "8.11.0"
+
+//ecsTimeLayout RFC3339 with milliseconds, the @timestamp of ECS
+const ecsTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+//ECSFormatter render one Elastic Common Schema JSON object per entry:
+//@timestamp in UTC with milliseconds, log.level, message, log.origin for the
+//caller, service.version for SetVersionTag and event.sequence for SetSequence.
+//An "error" field becomes error.message. Other fields are custom top level
+//fields, a group named like an ECS object (e.g. Group("service", ...)) is
+//merged into it and a field clashing with a key already set goes to labels
+type ECSFormatter struct {
+	fieldFormatter
+}
+
+//Format Formatter interface
+func (f *ECSFormatter) Format(e *Entry) []byte {
+	obj := &fieldObject{}
+	obj.set("@timestamp", e.When.UTC().Format(ecsTimeLayout))
+	logObj := &fieldObject{}
+	logObj.set("level", levelNames[e.Level])
+	origin := &fieldObject{}
+	if len(e.Caller) > 0 {
+		file := &fieldObject{}
+		name, line := e.Caller, ""
+		if i := strings.LastIndexByte(name, ':'); i >= 0 {
+			name, line = name[:i], name[i+1:]
+		}
+		file.set("name", name)
+		if n, err := strconv.Atoi(line); err == nil {
+			file.set("line", n)
+		}
+		origin.set("file", file)
+	}
+	if len(e.Func) > 0 {
+		origin.set("function", e.Func)
+	}
+	if len(origin.keys) > 0 {
+		logObj.set("origin", origin)
+	}
+	obj.set("log", logObj)
+	if len(e.Msg) > 0 {
+		obj.set("message", e.Msg)
+	}
+	obj.set("ecs", ecsObject("version", ecsVersion))
+	if len(e.Version) > 0 {
+		obj.set("service", ecsObject("version", e.Version))
+	}
+	if e.Seq > 0 {
+		obj.set("event", ecsObject("sequence", e.Seq))
+	}
+	fields := &fieldObject{format: f.format}
+	fields.add(e.Fields)
+	if err, ok := fields.values["error"]; ok {
+		if _, group := err.(*fieldObject); !group {
+			fields.values["error"] = ecsObject("message", err)
+		}
+	}
+	labels := &fieldObject{}
+	mergeECS(obj, fields, "", labels)
+	if len(labels.keys) > 0 {
+		mergeECS(obj, ecsObject("labels", labels), "", &fieldObject{})
+	}
+	var buf bytes.Buffer
+	writeJSONObject(&buf, obj)
+	return buf.Bytes()
+}
+
+func ecsObject(key string, value interface{}) *fieldObject {
+	obj := &fieldObject{}
+	obj.set(key, value)
+	return obj
+}
+
+//mergeECS add the keys of src to dst, merging the objects present in both.
+//The ones clashing with a value of dst are set in labels by their dotted path
+func mergeECS(dst, src *fieldObject, path string, labels *fieldObject) {
+	for _, key := range src.keys {
+		value := src.values[key]
+		current, taken := dst.values[key]
+		if !taken {
+			dst.set(key, value)
+			continue
+		}
+		sub, ok := value.(*fieldObject)
+		into, merge := current.(*fieldObject)
+		if ok && merge {
+			mergeECS(into, sub, path+key+".", labels)
+			continue
+		}
+		labels.set(path+key, value)
+	}
+}
+
+func init() {
+	RegisterFormatter("ecs", func() Formatter { return &ECSFormatter{} })
+}
@@ -0,0 +1,43 @@
+package logg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestECSFormatter(t *testing.T) {
+	e := &Entry{When: time.Date(2020, 1, 2, 3, 4, 5, 123456789, time.FixedZone("CET", 3600)), Level: LevelWarn,
+		Msg: "hello", Caller: "main.go:12", Func: "main.run", Version: "1.4.2", Seq: 7,
+		Fields: []Field{String("request_id", "r1"), Err(errors.New("boom")),
+			Group("service", String("name", "api"), String("version", "2")), {"message", "clash"}}}
+	want := `{"@timestamp":"2020-01-02T02:04:05.123Z",` +
+		`"log":{"level":"warn","origin":{"file":{"name":"main.go","line":12},"function":"main.run"}},` +
+		`"message":"hello","ecs":{"version":"8.11.0"},"service":{"version":"1.4.2","name":"api"},` +
+		`"event":{"sequence":7},"request_id":"r1","error":{"message":"boom"},` +
+		`"labels":{"service.version":"2","message":"clash"}}`
+	if got := string((&ECSFormatter{}).Format(e)); got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestECSFileAppender(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ecs.log")
+	log := NewLogger(10)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","format":"ecs"}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("started")
+	log.Close()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := strings.TrimSuffix(string(data), "\n")
+	if !strings.HasPrefix(line, `{"@timestamp":"`) || !strings.HasSuffix(line, `"log":{"level":"info"},"message":"started","ecs":{"version":"8.11.0"}}`) {
+		t.Fatalf("unexpected ecs line %q", data)
+	}
+}
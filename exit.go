@@ -0,0 +1,30 @@
+package logg
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+//exitFunc ends the process after a fatal message, replaced by the tests
+var exitFunc = os.Exit
+
+//EnableFatalExit close the logger, flushing every appender, then exit the
+//process after each fatal message, with the code of SetFatalExitCode.
+//FatalAt and FlushOnPanic never exit
+func (log *BaseLogger) EnableFatalExit(b bool) {
+	var v int32
+	if b {
+		v = 1
+	}
+	atomic.StoreInt32(&log.fatalExit, v)
+}
+
+//SetFatalExitCode the exit code of EnableFatalExit, 1 by default
+func (log *BaseLogger) SetFatalExitCode(code int) {
+	atomic.StoreInt32(&log.fatalExitCode, int32(code))
+}
+
+func (log *BaseLogger) exitFatal() {
+	log.Close()
+	exitFunc(int(atomic.LoadInt32(&log.fatalExitCode)))
+}
@@ -0,0 +1,68 @@
+package logg
+
+import (
+	"os"
+	"testing"
+)
+
+func stubExit(t *testing.T) *[]int {
+	var codes []int
+	exitFunc = func(code int) {
+		codes = append(codes, code)
+	}
+	t.Cleanup(func() {
+		exitFunc = os.Exit
+	})
+	return &codes
+}
+
+func TestFatalExitCode(t *testing.T) {
+	codes := stubExit(t)
+	log := NewLogger(10)
+	rec := &Recorder{}
+	log.AddAppender("rec", rec)
+	log.Async()
+	log.EnableFatalExit(true)
+	log.SetFatalExitCode(3)
+	log.Error("not exiting")
+	if len(*codes) != 0 {
+		t.Fatalf("exited on error: %v", *codes)
+	}
+	log.Fatal("exiting")
+	if len(*codes) != 1 || (*codes)[0] != 3 {
+		t.Fatalf("exit codes %v", *codes)
+	}
+	if got := rec.Lines(); len(got) != 2 || got[1] != "[F] exiting" {
+		t.Fatalf("not flushed before exit: %q", got)
+	}
+}
+
+func TestFatalExitDeferred(t *testing.T) {
+	codes := stubExit(t)
+	log := NewLogger(10)
+	log.Defer()
+	log.Async()
+	log.EnableFatalExit(true)
+	rec := addRecorder(log, "rec")
+	log.Info("starting")
+	log.Fatal("startup failed")
+	if len(*codes) != 1 {
+		t.Fatalf("exit codes %v", *codes)
+	}
+	if got := rec.Lines(); len(got) != 2 || got[1] != "[F] startup failed" {
+		t.Fatalf("startup logs lost: %q", got)
+	}
+}
+
+func TestFatalExitDisabled(t *testing.T) {
+	codes := stubExit(t)
+	log := NewLogger(10)
+	defer log.Close()
+	log.Fatal("default")
+	log.EnableFatalExit(true)
+	log.EnableFatalExit(false)
+	log.With(Field{"k", 1}).Fatal("disabled")
+	if len(*codes) != 0 {
+		t.Fatalf("exited with %v", *codes)
+	}
+}
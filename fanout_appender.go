@@ -0,0 +1,177 @@
+package logg
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//fanoutTimeout how long Flush and Destroy wait for a child
+const fanoutTimeout = 5 * time.Second
+
+//FanoutAppender write to each child appender from its own goroutine through a
+//bounded buffer, so a slow or failing child doesn't hold back the others.
+//Messages for a child whose buffer is full are dropped and counted.
+type FanoutAppender struct {
+	dropped  uint64
+	children []*fanoutChild
+	lock     sync.RWMutex //closed and the items channels
+	closed   bool
+	timeout  time.Duration
+}
+
+type fanoutItem struct {
+	e   *Entry
+	ack chan struct{}
+}
+
+type fanoutChild struct {
+	Appender
+	items chan fanoutItem
+	done  chan struct{} //closed when run returns
+}
+
+//NewFanoutAppender start one goroutine per child, each buffering up to bufferLen
+//messages. Children must already be initialized
+func NewFanoutAppender(bufferLen int, children ...Appender) *FanoutAppender {
+	f := &FanoutAppender{timeout: fanoutTimeout}
+	for _, out := range children {
+		child := &fanoutChild{Appender: out, items: make(chan fanoutItem, bufferLen), done: make(chan struct{})}
+		f.children = append(f.children, child)
+		go f.run(child)
+	}
+	return f
+}
+
+func (f *FanoutAppender) run(child *fanoutChild) {
+	defer close(child.done)
+	for item := range child.items {
+		if item.ack != nil {
+			child.Flush()
+			close(item.ack)
+			continue
+		}
+		var err error
+		if ea, ok := child.Appender.(EntryAppender); ok {
+			err = ea.WriteEntry(item.e)
+		} else {
+			err = child.WriteMsg(item.e.When, item.e.Text(), item.e.Level)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "logg: fanout child %T error:%v\n", child.Appender, err)
+		}
+	}
+}
+
+//Init children are initialized by their creator, config is ignored
+func (f *FanoutAppender) Init(config string) error {
+	return nil
+}
+
+//WriteMsg queue msg for every child
+func (f *FanoutAppender) WriteMsg(when time.Time, msg string, level int) error {
+	var goroutine uint64
+	if f.includesGoroutine() {
+		goroutine = goroutineID() //the children write from their own goroutine
+	}
+	for _, child := range f.children {
+		e := &Entry{When: when, Level: level, Msg: msg, Goroutine: goroutine, text: msg}
+		f.queue(child, e)
+	}
+	return nil
+}
+
+func (f *FanoutAppender) includesGoroutine() bool {
+	for _, child := range f.children {
+		if wantsGoroutine(child.Appender) {
+			return true
+		}
+	}
+	return false
+}
+
+//WriteEntry queue a copy of e for every child
+func (f *FanoutAppender) WriteEntry(e *Entry) error {
+	for _, child := range f.children {
+		c := *e
+		c.stamp = nil //points into the stampBuf of e, which goes back to the pool
+		f.queue(child, &c)
+	}
+	return nil
+}
+
+//queue drop e when the buffer of child is full or the fanout destroyed
+func (f *FanoutAppender) queue(child *fanoutChild, e *Entry) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	if !f.closed {
+		select {
+		case child.items <- fanoutItem{e: e}:
+			return
+		default:
+		}
+	}
+	atomic.AddUint64(&f.dropped, 1)
+}
+
+//Dropped the number of messages dropped because a child's buffer was full
+func (f *FanoutAppender) Dropped() uint64 {
+	return atomic.LoadUint64(&f.dropped)
+}
+
+//Flush wait until every child wrote what was queued before, then flush it.
+//A child whose buffer is full is not flushed and counted in Dropped, a stuck
+//one is given up after 5 seconds
+func (f *FanoutAppender) Flush() {
+	acks := make([]chan struct{}, 0, len(f.children))
+	f.lock.RLock()
+	for _, child := range f.children {
+		if f.closed {
+			break
+		}
+		ack := make(chan struct{})
+		select {
+		case child.items <- fanoutItem{ack: ack}:
+			acks = append(acks, ack)
+		default:
+			atomic.AddUint64(&f.dropped, 1)
+		}
+	}
+	f.lock.RUnlock()
+	timer := time.NewTimer(f.timeout)
+	defer timer.Stop()
+	for _, ack := range acks {
+		select {
+		case <-ack:
+		case <-timer.C:
+			return
+		}
+	}
+}
+
+//Destroy drain, stop the goroutines and destroy the children. A child still
+//stuck after 5 seconds is left running and not destroyed
+func (f *FanoutAppender) Destroy() {
+	f.lock.Lock()
+	if f.closed {
+		f.lock.Unlock()
+		return
+	}
+	f.closed = true
+	for _, child := range f.children {
+		close(child.items)
+	}
+	f.lock.Unlock()
+	timer := time.NewTimer(f.timeout)
+	defer timer.Stop()
+	for _, child := range f.children {
+		select {
+		case <-child.done:
+			child.Destroy()
+		case <-timer.C:
+			fmt.Fprintf(os.Stderr, "logg: fanout child %T still busy, not destroyed\n", child.Appender)
+		}
+	}
+}
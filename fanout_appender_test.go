@@ -0,0 +1,91 @@
+package logg
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type blockingAppender struct {
+	Recorder
+	release chan struct{}
+}
+
+func (b *blockingAppender) WriteMsg(when time.Time, msg string, level int) error {
+	<-b.release
+	return b.Recorder.WriteMsg(when, msg, level)
+}
+
+func TestFanoutAppenderSlowChild(t *testing.T) {
+	slow := &blockingAppender{release: make(chan struct{})}
+	fast := &Recorder{}
+	log := NewLogger(10)
+	fanout := NewFanoutAppender(4, slow, fast)
+	log.AddAppender("fanout", fanout)
+	for i := 0; i < 10; i++ {
+		log.Info("msg %d", i)
+		time.Sleep(2 * time.Millisecond)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(fast.Lines()) < 10 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := len(fast.Lines()); n != 10 {
+		t.Fatalf("fast child got %d messages while the slow one blocks", n)
+	}
+	if len(slow.Lines()) != 0 {
+		t.Fatal("slow child should still be blocked")
+	}
+	if fanout.Dropped() == 0 {
+		t.Fatal("slow child's full buffer should drop messages")
+	}
+	close(slow.release)
+	log.Close()
+	if n := len(slow.Lines()); n == 0 || n > 5 {
+		t.Fatalf("slow child should get its buffered messages, got %d", n)
+	}
+}
+
+func TestFanoutAppenderStuckChild(t *testing.T) {
+	stuck := &blockingAppender{release: make(chan struct{})}
+	defer close(stuck.release)
+	fast := &Recorder{}
+	fanout := NewFanoutAppender(4, stuck, fast)
+	fanout.timeout = 20 * time.Millisecond
+	fanout.WriteMsg(time.Now(), "[I] first", LevelInfo)
+	fanout.WriteMsg(time.Now(), "[I] second", LevelInfo)
+	fanout.WriteMsg(time.Now(), "[I] third", LevelInfo)
+	done := make(chan struct{})
+	go func() {
+		fanout.Flush()
+		fanout.Destroy()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a stuck child hangs Flush and Destroy")
+	}
+	if n := len(fast.Lines()); n != 3 {
+		t.Fatalf("fast child got %d messages", n)
+	}
+	fanout.WriteMsg(time.Now(), "[I] after destroy", LevelInfo)
+	if fanout.Dropped() == 0 {
+		t.Fatal("full buffer and destroyed fanout should drop messages")
+	}
+}
+
+func TestFanoutAppenderStructuredMsg(t *testing.T) {
+	var buf strings.Builder
+	c := newConsoleAppender().(*consoleWriter)
+	c.lg = newLogWriter(&buf)
+	if err := c.Init(`{"color":false,"notime":true,"format":"json"}`); err != nil {
+		t.Fatal(err)
+	}
+	fanout := NewFanoutAppender(4, c)
+	fanout.WriteMsg(time.Now(), "hello", LevelInfo)
+	fanout.Destroy()
+	if !strings.Contains(buf.String(), `"msg":"hello"`) {
+		t.Fatalf("got %q", buf.String())
+	}
+}
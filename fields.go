@@ -0,0 +1,100 @@
+package logg
+
+//Field a key value pair attached to a log message. Fields are rendered in
+//the order they were given, maps in field values in sorted key order, so a
+//line is the same from one run to the next in every format
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+//Group make a field holding fields nested under key, an empty key inlines them
+func Group(key string, fields ...Field) Field {
+	return Field{Key: key, Value: fields}
+}
+
+//FieldLogger a view of a BaseLogger adding fields to every message,
+//it shares the appenders, level and async worker of its BaseLogger
+type FieldLogger struct {
+	base   *BaseLogger
+	fields []Field
+	groups []string
+}
+
+//With return a FieldLogger adding fields to every message
+func (log *BaseLogger) With(fields ...Field) *FieldLogger {
+	return (&FieldLogger{base: log}).With(fields...)
+}
+
+//WithGroup return a FieldLogger nesting the following fields under name
+func (log *BaseLogger) WithGroup(name string) *FieldLogger {
+	return (&FieldLogger{base: log}).WithGroup(name)
+}
+
+//With return a FieldLogger adding fields inside the currently open groups
+func (l *FieldLogger) With(fields ...Field) *FieldLogger {
+	if len(fields) == 0 {
+		return l
+	}
+	for i := len(l.groups) - 1; i >= 0; i-- {
+		fields = []Field{Group(l.groups[i], fields...)}
+	}
+	n := &FieldLogger{base: l.base, groups: l.groups}
+	n.fields = make([]Field, 0, len(l.fields)+len(fields))
+	n.fields = append(n.fields, l.fields...)
+	n.fields = append(n.fields, fields...)
+	return n
+}
+
+//WithGroup return a FieldLogger nesting the following fields under name,
+//an empty name is ignored
+func (l *FieldLogger) WithGroup(name string) *FieldLogger {
+	if len(name) == 0 {
+		return l
+	}
+	n := &FieldLogger{base: l.base, fields: l.fields}
+	n.groups = make([]string, 0, len(l.groups)+1)
+	n.groups = append(n.groups, l.groups...)
+	n.groups = append(n.groups, name)
+	return n
+}
+
+//Fatal log.Fatal
+func (l *FieldLogger) Fatal(format string, v ...interface{}) {
+	if l.base.skipped(LevelFatal) {
+		return
+	}
+	l.base.writeMsg(LevelFatal, sprintf(format, v...), l.fields)
+}
+
+//Error log.Error
+func (l *FieldLogger) Error(format string, v ...interface{}) {
+	if l.base.skipped(LevelError) {
+		return
+	}
+	l.base.writeMsg(LevelError, sprintf(format, v...), l.fields)
+}
+
+//Warn log.Warn
+func (l *FieldLogger) Warn(format string, v ...interface{}) {
+	if l.base.skipped(LevelWarn) {
+		return
+	}
+	l.base.writeMsg(LevelWarn, sprintf(format, v...), l.fields)
+}
+
+//Info log.Info
+func (l *FieldLogger) Info(format string, v ...interface{}) {
+	if l.base.skipped(LevelInfo) {
+		return
+	}
+	l.base.writeMsg(LevelInfo, sprintf(format, v...), l.fields)
+}
+
+//Debug log.Debug
+func (l *FieldLogger) Debug(format string, v ...interface{}) {
+	if l.base.skipped(LevelDebug) {
+		return
+	}
+	l.base.writeMsg(LevelDebug, sprintf(format, v...), l.fields)
+}
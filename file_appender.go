@@ -1,43 +1,96 @@
 package logg
 
 import (
+	"compress/gzip"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 )
 
 type fileLogWriter struct {
 	sync.Mutex
 	Filename   string `json:"filename"`
 	fileWriter *os.File
+	//Gzip the file while writing it, maxsize then counts compressed bytes
+	LiveCompress bool `json:"livecompress"`
+	gz           *gzip.Writer
 	//Rotate at size
 	MaxSize        int `json:"maxsize"`
 	maxSizeCurSize int
 
 	//Rotate at daily
-	Daily         bool `json:"daily"`
-	MaxDays       int  `json:"maxdays"` //日志最长保留时间
+	Daily         bool  `json:"daily"`
+	MaxDays       int   `json:"maxdays"`      //日志最长保留时间
+	MaxBackups    int   `json:"maxbackups"`   //rotated files kept, 0 keeps them all
+	MaxTotalSize  int64 `json:"maxtotalsize"` //bytes of rotated files kept, 0 is unlimited
 	dailyOpenDate int
 
-	Rotate       bool `json:"rotate"`
-	Level        int  `json:"Level"`
+	EnableRotate bool `json:"rotate"`
+	//Go time layout of the rotated files' suffix, {isoweek} and {host} are
+	//replaced by the ISO week like 2026-W09 and the hostname
+	RotateSuffix string `json:"rotatesuffix"`
+	Level        int    `json:"level"`    //"Level" still matches, json keys are case insensitive
+	MaxLevel     int    `json:"maxlevel"` //most severe level written, LevelFatal by default
+	Format       string `json:"format"`
+	Layout       string `json:"timelayout"` //Go time layout of the text output
+	formatter    Formatter
 	fileNameOnly string
 	fileSuffix   string
+	rotatedName  *regexp.Regexp //the names doRotate gives, see rotatedPattern
+
+	//Sync at most once per interval after writes, in milliseconds
+	SyncInterval int `json:"syncinterval"`
+	//Sync after every write
+	SyncEveryWrite bool `json:"synceverywrite"`
+	//Open the file with O_SYNC, every write waits for the disk: many times slower
+	OSync bool `json:"osync"`
+	//Tries to open the file at Init, for a mount not ready yet, 1 by default
+	OpenAttempts int `json:"openattempts"`
+	//Wait before the second try in milliseconds, doubled after each one
+	OpenBackoff int `json:"openbackoff"`
+	//Stat Filename at most once per interval before a write, in milliseconds,
+	//and reopen it when it was moved or replaced, by logrotate for example
+	ReopenCheck int `json:"reopencheck"`
+	lastCheck   time.Time
+	//Write \n and \r inside a record as the two characters `\n` and `\r`
+	EscapeNewlines bool `json:"escapenewlines"`
+	tagOptions
+	lineWrap
+	formatOptions
+	syncTimer *time.Timer
+	afterFunc func(d time.Duration, fn func()) *time.Timer
+	syncFile  func(*os.File) error
+	openFile  func(name string, flag int, perm os.FileMode) (*os.File, error)
+	onRotate  func(path string)
+	onError   func(err error)
+	onDelete  func(path string)
+	buf       []byte
 }
 
 func newFileAppender() Appender {
 	w := &fileLogWriter{
-		Filename: "",
-		MaxSize:  0, //0
-		Daily:    true,
-		MaxDays:  0, //
-		Rotate:   true,
-		Level:    LevelDebug,
+		Filename:     "",
+		MaxSize:      0, //0
+		Daily:        true,
+		MaxDays:      0, //
+		EnableRotate: true,
+		Level:        LevelDebug,
+		Layout:       timeLayout,
+		RotateSuffix: rotateSuffix,
+		syncFile:     (*os.File).Sync,
+		afterFunc:    time.AfterFunc,
+		openFile:     os.OpenFile,
+		OpenAttempts: 1,
+		OpenBackoff:  100,
 	}
 	return w
 }
@@ -49,24 +102,72 @@ func newFileAppender() Appender {
 //"maxlines":1000000,
 //"maxsize":1<<30,
 //"daily":true,
-//"maxDays":15,
+//"maxdays":15,
+//"maxbackups":10,
+//"maxtotalsize":1<<30,
 //"rotate":true,
+//"syncinterval":1000,
+//"synceverywrite":false,
+//"reopencheck":1000,
+//"openattempts":1,
+//"openbackoff":100,
+//"osync":false,
+//"escapenewlines":false,
+//"includepid":false,
+//"includegoroutine":false,
+//"lineprefix":"",
+//"linesuffix":"",
+//"lineending":"lf", //or "crlf"
+//"timelayout":"2006-01-02 15:04:05.000000",
+//"rotatesuffix":"2006-01-02",
+//"livecompress":false, //use a filename like "app.log.gz"
 //}
 func (f *fileLogWriter) Init(config string) error {
 	err := json.Unmarshal([]byte(config), f)
 	if err != nil {
 		return err
 	}
+	//"maxday" is accepted as an alias of "maxdays" (json keys are case insensitive)
+	var alias struct {
+		MaxDays *int `json:"maxdays"`
+		MaxDay  *int `json:"maxday"`
+	}
+	if json.Unmarshal([]byte(config), &alias) == nil && alias.MaxDays == nil && alias.MaxDay != nil {
+		f.MaxDays = *alias.MaxDay
+	}
 	if len(f.Filename) == 0 {
 		return errors.New("json config must have filename")
 	}
+	if len(f.Layout) == 0 {
+		f.Layout = timeLayout
+	}
+	if len(f.RotateSuffix) == 0 {
+		f.RotateSuffix = rotateSuffix
+	}
+	if err := checkRotateSuffix(f.RotateSuffix); err != nil {
+		return err
+	}
+	if err := f.lineWrap.init(); err != nil {
+		return err
+	}
+	if f.formatter, err = newFormatter(f.Format, f.formatOptions); err != nil {
+		return err
+	}
 	f.fileSuffix = filepath.Ext(f.Filename)
 	f.fileNameOnly = strings.TrimSuffix(f.Filename, f.fileSuffix)
 	if f.fileSuffix == "" {
 		f.fileSuffix = ".log"
 	}
-	err = f.startLogging()
-	return err
+	f.rotatedName = rotatedPattern(filepath.Base(f.fileNameOnly), f.RotateSuffix, f.fileSuffix)
+	backoff := time.Duration(f.OpenBackoff) * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		if err = f.startLogging(); err == nil || attempt >= f.OpenAttempts {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "FileLogAppender %q:open attempt %d error %s\n", f.Filename, attempt, err.Error())
+		time.Sleep(backoff)
+		backoff *= 2
+	}
 }
 
 func (f *fileLogWriter) startLogging() error {
@@ -75,14 +176,54 @@ func (f *fileLogWriter) startLogging() error {
 		return err
 	}
 	if f.fileWriter != nil {
-		f.fileWriter.Close()
+		f.closeFile()
 	}
 	f.fileWriter = file
-	return f.initFd()
+	if err := f.initFd(); err != nil {
+		return err
+	}
+	if f.LiveCompress {
+		f.gz = gzip.NewWriter(compressedFile{f})
+	}
+	return nil
+}
+
+//compressedFile count the bytes gzip writes to the file, under the appender lock
+type compressedFile struct {
+	f *fileLogWriter
+}
+
+func (c compressedFile) Write(b []byte) (int, error) {
+	n, err := c.f.fileWriter.Write(b)
+	c.f.maxSizeCurSize += n
+	return n, err
+}
+
+//closeFile end the gzip stream if any and close the file
+func (f *fileLogWriter) closeFile() error {
+	if f.gz != nil {
+		f.gz.Close()
+		f.gz = nil
+	}
+	return f.fileWriter.Close()
+}
+
+//sync flush the gzip stream if any and sync the file, must hold the lock
+func (f *fileLogWriter) sync() error {
+	if f.gz != nil {
+		if err := f.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	return f.syncFile(f.fileWriter)
 }
 
 func (f *fileLogWriter) createLogFile() (*os.File, error) {
-	fd, err := os.OpenFile(f.Filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0660)
+	flag := os.O_WRONLY | os.O_APPEND | os.O_CREATE
+	if f.OSync {
+		flag |= os.O_SYNC
+	}
+	fd, err := f.openFile(f.Filename, flag, 0660)
 	return fd, err
 }
 
@@ -102,34 +243,29 @@ func (f *fileLogWriter) needRotate(size int, day int) bool {
 		(f.Daily && day != f.dailyOpenDate)
 }
 
-func (f *fileLogWriter) doRotate(logTime time.Time) error {
+//doRotate rename the current file with logTime's date and reopen Filename,
+//numbered names are used for size rotation and forced rotation, and for a
+//daily rotation whose date-only name is taken
+func (f *fileLogWriter) doRotate(logTime time.Time, numbered bool) error {
 	_, err := os.Lstat(f.Filename)
 	if err != nil {
 		return err
 	}
-	num := 1
+	suffix := formatRotateSuffix(f.RotateSuffix, logTime)
 	fName := ""
-	//logTime.Add
-	dlt, err := time.ParseDuration("-24h")
-	logTime = logTime.Add(dlt)
-	//t := time.Date(logTime.Year(), logTime.Month(), logTime.Day(), 0, 0, 0)
-	//logTime.Truncate
-	if f.MaxSize > 0 {
-		for ; err == nil && num <= 999; num++ {
-			fName = f.fileNameOnly + fmt.Sprintf("_%s_%03d%s", logTime.Format("2006-01-02"), num, f.fileSuffix)
-			_, err = os.Lstat(fName)
-		}
-
-	} else {
-
-		fName = fmt.Sprintf("%s_%s%s", f.fileNameOnly, logTime.Format("2006-01-02"), f.fileSuffix)
+	if !numbered {
+		fName = fmt.Sprintf("%s_%s%s", f.fileNameOnly, suffix, f.fileSuffix)
+		_, err = os.Lstat(fName)
+	}
+	for num := 1; err == nil && num <= 999; num++ {
+		fName = f.fileNameOnly + fmt.Sprintf("_%s_%03d%s", suffix, num, f.fileSuffix)
 		_, err = os.Lstat(fName)
 	}
 
 	if err == nil {
 		return errors.New("Rotate: can not find free log number to rename " + f.Filename + "\n")
 	}
-	f.fileWriter.Close()
+	f.closeFile()
 	errRename := os.Rename(f.Filename, fName)
 	if errRename != nil {
 		return errors.New("Rotate: rename error " + errRename.Error())
@@ -138,12 +274,142 @@ func (f *fileLogWriter) doRotate(logTime time.Time) error {
 	if errStartLogging != nil {
 		return errors.New("Rotate: startLogging error " + errStartLogging.Error())
 	}
-	go f.deleteOldLog()
+	if fn := f.onRotate; fn != nil {
+		callHook("rotate", func() { fn(fName) })
+	}
+	go f.deleteOldLog(f.onDelete, f.MaxDays)
 	return nil
 }
 
-func (f *fileLogWriter) deleteOldLog() {
-	if f.MaxDays <= 0 {
+//SetMaxSize FileAppenderControl interface, checked at the next write
+func (f *fileLogWriter) SetMaxSize(maxSize int) {
+	f.Lock()
+	f.MaxSize = maxSize
+	f.Unlock()
+}
+
+//SetMaxDays FileAppenderControl interface, applied at the next rotation
+func (f *fileLogWriter) SetMaxDays(maxDays int) {
+	f.Lock()
+	f.MaxDays = maxDays
+	f.Unlock()
+}
+
+//SetDaily FileAppenderControl interface, checked at the next write
+func (f *fileLogWriter) SetDaily(daily bool) {
+	f.Lock()
+	f.Daily = daily
+	f.Unlock()
+}
+
+//OnRotate call fn with the name of every file rotated away
+func (f *fileLogWriter) OnRotate(fn func(path string)) {
+	f.Lock()
+	f.onRotate = fn
+	f.Unlock()
+}
+
+//OnError call fn with the rotate, write and sync errors
+func (f *fileLogWriter) OnError(fn func(err error)) {
+	f.Lock()
+	f.onError = fn
+	f.Unlock()
+}
+
+//OnDelete call fn with every old file removed because of maxdays
+func (f *fileLogWriter) OnDelete(fn func(path string)) {
+	f.Lock()
+	f.onDelete = fn
+	f.Unlock()
+}
+
+//reportError hand err to the error hook, must hold the lock
+func (f *fileLogWriter) reportError(err error) {
+	if fn := f.onError; fn != nil {
+		callHook("error", func() { fn(err) })
+	}
+}
+
+//callHook run fn in its own goroutine, out of the appender lock, a panic
+//in fn is printed to stderr
+func callHook(name string, fn func()) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Fprintf(os.Stderr, "FileLogAppender %s hook panic:%v\n", name, r)
+			}
+		}()
+		fn()
+	}()
+}
+
+const rotateSuffix = "2006-01-02"
+
+//formatRotateSuffix render the rotated files' suffix of logTime
+func formatRotateSuffix(layout string, logTime time.Time) string {
+	suffix := logTime.Format(layout)
+	if strings.Contains(suffix, "{isoweek}") {
+		year, week := logTime.ISOWeek()
+		suffix = strings.Replace(suffix, "{isoweek}", fmt.Sprintf("%d-W%02d", year, week), -1)
+	}
+	if strings.Contains(suffix, "{host}") {
+		host, _ := os.Hostname()
+		suffix = strings.Replace(suffix, "{host}", host, -1)
+	}
+	return suffix
+}
+
+//rotatedPattern match the base names of the files doRotate renames name to:
+//name_, the rotatesuffix, an optional _NNN number then ext. Digits and letters
+//of the suffix match any run of them so any date fits
+func rotatedPattern(name, layout, ext string) *regexp.Regexp {
+	host, _ := os.Hostname()
+	sample := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC).Format(layout)
+	var b strings.Builder
+	b.WriteString("^" + regexp.QuoteMeta(name) + "_")
+	for len(sample) > 0 {
+		n := 1
+		switch c := sample[0]; {
+		case strings.HasPrefix(sample, "{isoweek}"):
+			n = len("{isoweek}")
+			b.WriteString(`\d{4}-W\d{2}`)
+		case strings.HasPrefix(sample, "{host}"):
+			n = len("{host}")
+			b.WriteString(regexp.QuoteMeta(host))
+		case c >= '0' && c <= '9':
+			for n < len(sample) && sample[n] >= '0' && sample[n] <= '9' {
+				n++
+			}
+			b.WriteString(`\d+`)
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			for n < len(sample) && (sample[n] >= 'a' && sample[n] <= 'z' || sample[n] >= 'A' && sample[n] <= 'Z') {
+				n++
+			}
+			b.WriteString(`[A-Za-z]+`)
+		default:
+			b.WriteString(regexp.QuoteMeta(sample[:1]))
+		}
+		sample = sample[n:]
+	}
+	b.WriteString(`(_\d{3})?` + regexp.QuoteMeta(ext) + "$")
+	return regexp.MustCompile(b.String())
+}
+
+//checkRotateSuffix refuse layouts making names that are empty or not
+//filesystem safe, like "15:04" or "2006/01"
+func checkRotateSuffix(layout string) error {
+	sample := formatRotateSuffix(layout, time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC))
+	if len(sample) == 0 || strings.ContainsAny(sample, "/\\:*?\"<>|") || strings.IndexFunc(sample, unicode.IsControl) >= 0 {
+		return errors.New("FileLogAppender rotatesuffix " + strconv.Quote(layout) + " makes unsafe file names like " + strconv.Quote(sample))
+	}
+	return nil
+}
+
+func (f *fileLogWriter) deleteOldLog(onDelete func(path string), maxDays int) {
+	if f.MaxBackups > 0 || f.MaxTotalSize > 0 {
+		f.deleteExtraBackups(onDelete)
+	}
+	if maxDays <= 0 {
 		return
 	}
 
@@ -155,10 +421,11 @@ func (f *fileLogWriter) deleteOldLog() {
 			}
 		}()
 
-		if !info.IsDir() && (info.ModTime().Unix() < (time.Now().Unix() - int64(60*60*24*f.MaxDays))) {
-			if strings.HasPrefix(filepath.Base(path), f.fileNameOnly) &&
-				strings.HasSuffix(filepath.Base(path), f.fileSuffix) {
-				os.Remove(path)
+		if !info.IsDir() && (info.ModTime().Unix() < (time.Now().Unix() - int64(60*60*24*maxDays))) {
+			if f.rotatedName.MatchString(filepath.Base(path)) {
+				if os.Remove(path) == nil && onDelete != nil {
+					callHook("delete", func() { onDelete(path) })
+				}
 			}
 
 		}
@@ -166,35 +433,224 @@ func (f *fileLogWriter) deleteOldLog() {
 	})
 }
 
+//deleteExtraBackups remove the oldest rotated files beyond MaxBackups files
+//or MaxTotalSize bytes
+func (f *fileLogWriter) deleteExtraBackups(onDelete func(path string)) {
+	dir := filepath.Dir(f.Filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	type backup struct {
+		path string
+		mod  time.Time
+		size int64
+	}
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !f.rotatedName.MatchString(name) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			backups = append(backups, backup{filepath.Join(dir, name), info.ModTime(), info.Size()})
+		}
+	}
+	//newest first, numbered names of the same instant sort by number
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].mod.Equal(backups[j].mod) {
+			return backups[i].mod.After(backups[j].mod)
+		}
+		return backups[i].path > backups[j].path
+	})
+	keep := len(backups)
+	if f.MaxBackups > 0 && keep > f.MaxBackups {
+		keep = f.MaxBackups
+	}
+	if f.MaxTotalSize > 0 {
+		var total int64
+		for i, b := range backups[:keep] {
+			if total += b.size; total > f.MaxTotalSize {
+				keep = i
+				break
+			}
+		}
+	}
+	for _, b := range backups[keep:] {
+		path := b.path
+		if os.Remove(path) == nil && onDelete != nil {
+			callHook("delete", func() { onDelete(path) })
+		}
+	}
+}
+
 func (f *fileLogWriter) WriteMsg(when time.Time, msg string, level int) error {
-	if level > f.Level {
+	if level > f.Level || level < f.MaxLevel {
+		return nil
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.buf = when.AppendFormat(f.buf[:0], f.Layout)
+	f.buf = append(f.buf, f.tagText(msg)...)
+	return f.writeLine(f.buf)
+}
+
+//WriteEntry render e with the configured format
+func (f *fileLogWriter) WriteEntry(e *Entry) error {
+	if e.Level > f.Level || e.Level < f.MaxLevel {
 		return nil
 	}
-	msg = when.Format("2006-01-02 15:04:05") + msg + "\n"
-	if f.Rotate {
-		if f.needRotate(len(msg), when.Day()) {
-			f.Lock()
-			if err := f.doRotate(when); err != nil {
+	e = f.tag(e)
+	f.Lock()
+	defer f.Unlock()
+	if f.formatter == nil {
+		f.buf = e.appendStamp(f.buf[:0], f.Layout)
+		f.buf = e.appendText(f.buf)
+	} else {
+		f.buf = append(f.buf[:0], f.formatter.Format(e)...)
+	}
+	return f.writeLine(f.buf)
+}
+
+//writeLine terminate the record, rotate if needed then write it, must hold the lock
+func (f *fileLogWriter) writeLine(record []byte) error {
+	if f.EscapeNewlines {
+		record = escapeNewlines(record)
+	}
+	if len(f.LinePrefix) > 0 {
+		record = append([]byte(f.LinePrefix), record...)
+	}
+	record = append(record, f.LineSuffix...)
+	msg := f.end(record)
+	if f.ReopenCheck > 0 {
+		f.checkReplaced()
+	}
+	if f.EnableRotate {
+		//the wall clock, not the message time which may be in the past with InfoAt
+		now := time.Now()
+		if f.needRotate(len(msg), now.Day()) {
+			//the file holds the previous day only when the day changed
+			logTime := now
+			if f.Daily && now.Day() != f.dailyOpenDate {
+				logTime = now.Add(-24 * time.Hour)
+			}
+			if err := f.doRotate(logTime, f.MaxSize > 0); err != nil {
 				fmt.Fprintf(os.Stderr, "FileLogAppender %q:%s\n", f.Filename, err.Error())
+				f.reportError(err)
 			}
-			f.Unlock()
 		}
 	}
-	f.Lock()
-	_, err := f.fileWriter.Write([]byte(msg))
-	if err == nil {
+	var err error
+	if f.gz != nil {
+		_, err = f.gz.Write(msg)
+	} else if _, err = f.fileWriter.Write(msg); err == nil {
 		f.maxSizeCurSize += len(msg)
 	}
-	f.Unlock()
+	if err == nil {
+		if f.SyncEveryWrite {
+			err = f.sync()
+		} else {
+			f.scheduleSync()
+		}
+	}
+	if err != nil {
+		f.reportError(err)
+	}
+	f.buf = msg
 	return err
 }
 
+//scheduleSync start the sync timer unless one is pending, must hold the lock
+func (f *fileLogWriter) scheduleSync() {
+	if f.SyncInterval <= 0 || f.syncTimer != nil {
+		return
+	}
+	f.syncTimer = f.afterFunc(time.Duration(f.SyncInterval)*time.Millisecond, func() {
+		f.Lock()
+		f.syncTimer = nil
+		f.sync()
+		f.Unlock()
+	})
+}
+
+//Rotate force a rotation now, the rotated file is named with today's date
+func (f *fileLogWriter) Rotate() error {
+	f.Lock()
+	defer f.Unlock()
+	return f.doRotate(time.Now(), true)
+}
+
+//Reopen close the current file and open Filename again, used after the file
+//was moved away by an external tool like logrotate
+func (f *fileLogWriter) Reopen() error {
+	f.Lock()
+	defer f.Unlock()
+	return f.startLogging()
+}
+
+//checkReplaced reopen Filename when it is no longer the open file, at most
+//once per ReopenCheck, must hold the lock
+func (f *fileLogWriter) checkReplaced() {
+	now := time.Now()
+	if now.Sub(f.lastCheck) < time.Duration(f.ReopenCheck)*time.Millisecond {
+		return
+	}
+	f.lastCheck = now
+	open, err := f.fileWriter.Stat()
+	if err != nil {
+		return
+	}
+	if onDisk, err := os.Stat(f.Filename); err == nil && os.SameFile(open, onDisk) {
+		return
+	}
+	if err := f.startLogging(); err != nil {
+		fmt.Fprintf(os.Stderr, "FileLogAppender %q:reopen error %s\n", f.Filename, err.Error())
+		f.reportError(err)
+	}
+}
+
+//HealthCheck check Filename is still the open file and can be written
+func (f *fileLogWriter) HealthCheck() error {
+	f.Lock()
+	defer f.Unlock()
+	if f.fileWriter == nil {
+		return errors.New("FileLogAppender " + f.Filename + " is not open")
+	}
+	open, err := f.fileWriter.Stat()
+	if err != nil {
+		return err
+	}
+	onDisk, err := os.Stat(f.Filename)
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(open, onDisk) {
+		return errors.New("FileLogAppender " + f.Filename + " was replaced, Reopen it")
+	}
+	probe, err := os.OpenFile(f.Filename, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	return probe.Close()
+}
+
 func (f *fileLogWriter) Flush() {
+	f.Lock()
+	if f.gz != nil {
+		f.gz.Flush()
+	}
+	f.Unlock()
 	f.fileWriter.Sync()
 }
 
 func (f *fileLogWriter) Destroy() {
-	f.fileWriter.Close()
+	f.Lock()
+	if f.syncTimer != nil {
+		f.syncTimer.Stop()
+		f.syncTimer = nil
+	}
+	f.closeFile()
+	f.Unlock()
 }
 
 func init() {
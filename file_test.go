@@ -1,6 +1,18 @@
 package logg
 
-import "testing"
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
 
 func TestFileAppender(t *testing.T) {
 	log := NewLogger(100)
@@ -15,3 +27,813 @@ func TestFileAppender(t *testing.T) {
 	log.Close()
 
 }
+
+func TestFileAppenderRotate(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "rotate.log")
+	log := NewLogger(100)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`"}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("before rotate")
+	if err := log.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	rotated := filepath.Join(dir, "rotate_"+time.Now().Format("2006-01-02")+"_001.log")
+	data, err := os.ReadFile(rotated)
+	if err != nil {
+		t.Fatalf("rotated file %s not created: %v", rotated, err)
+	}
+	if !strings.Contains(string(data), "before rotate") {
+		t.Fatalf("rotated file missing message, got %q", data)
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 0 {
+		t.Fatalf("live file should be fresh after rotate, size %d", info.Size())
+	}
+	log.Close()
+}
+
+func TestFileAppenderDailyAndSizeRotate(t *testing.T) {
+	dir := t.TempDir()
+	a := newFileAppender().(*fileLogWriter)
+	if err := a.Init(`{"filename":"` + filepath.Join(dir, "both.log") + `","daily":true,"maxsize":100}`); err != nil {
+		t.Fatal(err)
+	}
+	var errs int32
+	a.onError = func(err error) { atomic.AddInt32(&errs, 1) }
+	for i := 0; i < 20; i++ {
+		if i%4 == 0 {
+			//as if the day changed, every daily rotation lands on the same date
+			a.dailyOpenDate = 0
+		}
+		a.WriteMsg(time.Now(), "[I] message "+strconv.Itoa(i)+" "+strings.Repeat("x", 40), LevelInfo)
+		if i == 9 {
+			//the second daily rotation alone then finds the date-only name taken
+			a.SetMaxSize(0)
+		}
+	}
+	a.Destroy()
+	time.Sleep(10 * time.Millisecond)
+	if n := atomic.LoadInt32(&errs); n != 0 {
+		t.Fatalf("%d rotation errors", n)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "both_*.log"))
+	if len(matches) < 6 {
+		t.Fatalf("expected size and daily rotations, got %v", matches)
+	}
+	var total int
+	for _, name := range append(matches, filepath.Join(dir, "both.log")) {
+		data, _ := os.ReadFile(name)
+		total += strings.Count(string(data), "message ")
+	}
+	if total != 20 {
+		t.Fatalf("messages lost across rotations, got %d", total)
+	}
+}
+
+func TestFileAppenderOnRotate(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "hook.log")
+	log := NewLogger(100)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`"}`); err != nil {
+		t.Fatal(err)
+	}
+	rotated := make(chan string, 1)
+	log.OnRotate(func(path string) { rotated <- path })
+	log.Info("before rotate")
+	if err := log.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case path := <-rotated:
+		if want := filepath.Join(dir, "hook_"+time.Now().Format("2006-01-02")+"_001.log"); path != want {
+			t.Fatalf("hook called with %s, want %s", path, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("rotate hook not called")
+	}
+	log.Close()
+}
+
+func TestFileAppenderOnRotateBeforeAttach(t *testing.T) {
+	dir := t.TempDir()
+	log := NewLogger(100)
+	rotated := make(chan string, 2)
+	log.OnRotate(func(path string) { rotated <- path })
+	if err := log.SetAppender("file", `{"filename":"`+filepath.Join(dir, "set.log")+`"}`); err != nil {
+		t.Fatal(err)
+	}
+	added, err := newAppender("file", `{"filename":"`+filepath.Join(dir, "added.log")+`"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.AddAppender("added", added)
+	log.Info("before rotate")
+	if err := log.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-rotated:
+		case <-time.After(time.Second):
+			t.Fatalf("rotate hook called for %d of the 2 appenders attached after OnRotate", i)
+		}
+	}
+	log.Close()
+}
+
+func TestFileAppenderReopen(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "reopen.log")
+	log := NewLogger(100)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`"}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("before reopen")
+	moved := filepath.Join(dir, "reopen.log.1")
+	if err := os.Rename(filename, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("after reopen")
+	log.Close()
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("original path not recreated: %v", err)
+	}
+	if !strings.Contains(string(data), "after reopen") || strings.Contains(string(data), "before reopen") {
+		t.Fatalf("unexpected content in reopened file %q", data)
+	}
+	data, err = os.ReadFile(moved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "before reopen") || strings.Contains(string(data), "after reopen") {
+		t.Fatalf("unexpected content in moved file %q", data)
+	}
+}
+
+func TestFileAppenderReopenCheck(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "check.log")
+	log := NewLogger(100)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","reopencheck":10}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("before rename")
+	moved := filepath.Join(dir, "check.log.1")
+	if err := os.Rename(filename, moved); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	log.Info("after rename")
+	log.Close()
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("not reopened: %v", err)
+	}
+	if !strings.Contains(string(data), "after rename") || strings.Contains(string(data), "before rename") {
+		t.Fatalf("reopened file got %q", data)
+	}
+	data, _ = os.ReadFile(moved)
+	if strings.Contains(string(data), "after rename") {
+		t.Fatalf("written to the renamed file %q", data)
+	}
+}
+
+func TestFileAppenderOpenAttempts(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "mount.log")
+	config := `{"filename":"` + filename + `","openattempts":3,"openbackoff":1}`
+	a := newFileAppender().(*fileLogWriter)
+	var calls int
+	a.openFile = func(name string, flag int, perm os.FileMode) (*os.File, error) {
+		if calls++; calls == 1 {
+			return nil, os.ErrNotExist
+		}
+		return os.OpenFile(name, flag, perm)
+	}
+	if err := a.Init(config); err != nil {
+		t.Fatalf("Init after a failed open: %v", err)
+	}
+	a.WriteMsg(time.Now(), "[I] mounted", LevelInfo)
+	a.Destroy()
+	if data, _ := os.ReadFile(filename); !strings.Contains(string(data), "mounted") || calls != 2 {
+		t.Fatalf("%d opens, file got %q", calls, data)
+	}
+
+	a = newFileAppender().(*fileLogWriter)
+	calls = 0
+	a.openFile = func(string, int, os.FileMode) (*os.File, error) {
+		calls++
+		return nil, os.ErrNotExist
+	}
+	if err := a.Init(config); err == nil || calls != 3 {
+		t.Fatalf("got %v after %d opens, want an error after 3", err, calls)
+	}
+}
+
+func TestFileAppenderSharedStamp(t *testing.T) {
+	dir := t.TempDir()
+	byMsg := newFileAppender()
+	byEntry := newFileAppender()
+	if err := byMsg.Init(`{"filename":"` + filepath.Join(dir, "msg.log") + `"}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := byEntry.Init(`{"filename":"` + filepath.Join(dir, "entry.log") + `"}`); err != nil {
+		t.Fatal(err)
+	}
+	e := &Entry{When: time.Now(), Level: LevelWarn, Msg: "same output", Caller: "file_test.go:1", Fields: []Field{{"k", 1}}}
+	byMsg.WriteMsg(e.When, e.Text(), e.Level)
+	byEntry.(EntryAppender).WriteEntry(e)
+	byMsg.Destroy()
+	byEntry.Destroy()
+	msgData, _ := os.ReadFile(filepath.Join(dir, "msg.log"))
+	entryData, _ := os.ReadFile(filepath.Join(dir, "entry.log"))
+	if len(msgData) == 0 || string(msgData) != string(entryData) {
+		t.Fatalf("output differs: %q vs %q", msgData, entryData)
+	}
+}
+
+func benchmarkTwoFileAppenders(b *testing.B, write func(a Appender, e *Entry)) {
+	dir := b.TempDir()
+	appenders := []Appender{newFileAppender(), newFileAppender()}
+	for i, a := range appenders {
+		if err := a.Init(`{"filename":"` + filepath.Join(dir, strconv.Itoa(i)+".log") + `"}`); err != nil {
+			b.Fatal(err)
+		}
+		defer a.Destroy()
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e := &Entry{When: time.Now(), Level: LevelInfo, Msg: "benchmark message"}
+		for _, a := range appenders {
+			write(a, e)
+		}
+	}
+}
+
+//BenchmarkTwoAppendersWriteMsg every appender renders the timestamp itself
+func BenchmarkTwoAppendersWriteMsg(b *testing.B) {
+	benchmarkTwoFileAppenders(b, func(a Appender, e *Entry) {
+		a.WriteMsg(e.When, e.Text(), e.Level)
+	})
+}
+
+//BenchmarkTwoAppendersEntry the timestamp is rendered once per message
+func BenchmarkTwoAppendersEntry(b *testing.B) {
+	benchmarkTwoFileAppenders(b, func(a Appender, e *Entry) {
+		a.(EntryAppender).WriteEntry(e)
+	})
+}
+
+func TestFileAppenderSyncInterval(t *testing.T) {
+	a := newFileAppender().(*fileLogWriter)
+	var scheduled []func()
+	a.afterFunc = func(d time.Duration, fn func()) *time.Timer {
+		if d != 20*time.Millisecond {
+			t.Errorf("sync scheduled after %v", d)
+		}
+		scheduled = append(scheduled, fn)
+		return time.NewTimer(time.Hour)
+	}
+	var syncs int
+	a.syncFile = func(*os.File) error {
+		syncs++
+		return nil
+	}
+	if err := a.Init(`{"filename":"` + filepath.Join(t.TempDir(), "sync.log") + `","syncinterval":20}`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		a.WriteMsg(time.Now(), "[I] sync me", LevelInfo)
+	}
+	if len(scheduled) != 1 || syncs != 0 {
+		t.Fatalf("%d syncs scheduled and %d done after 3 writes, want 1 and 0", len(scheduled), syncs)
+	}
+	scheduled[0]()
+	if syncs != 1 {
+		t.Fatalf("%d syncs when the interval elapsed", syncs)
+	}
+	a.WriteMsg(time.Now(), "[I] sync me", LevelInfo)
+	if len(scheduled) != 2 {
+		t.Fatalf("%d syncs scheduled after a write following a sync", len(scheduled))
+	}
+	scheduled[1]()
+	if len(scheduled) != 2 || syncs != 2 {
+		t.Fatalf("sync scheduled without new writes: %d scheduled, %d done", len(scheduled), syncs)
+	}
+	a.Destroy()
+}
+
+func TestFileAppenderMaxDaysKeys(t *testing.T) {
+	dir := t.TempDir()
+	for i, key := range []string{"maxdays", "maxDays", "maxday"} {
+		a := newFileAppender().(*fileLogWriter)
+		config := `{"filename":"` + filepath.Join(dir, key+".log") + `","` + key + `":` + strconv.Itoa(i+3) + `}`
+		if err := a.Init(config); err != nil {
+			t.Fatal(err)
+		}
+		if a.MaxDays != i+3 {
+			t.Fatalf("%s: MaxDays is %d, want %d", key, a.MaxDays, i+3)
+		}
+		a.Destroy()
+	}
+
+	ini := filepath.Join(dir, "maxday.ini")
+	content := "logg.appender.stdout = file\n" +
+		"logg.appender.stdout.file = " + filepath.Join(dir, "ini.log") + "\n" +
+		"logg.appender.stdout.maxday = 7\n"
+	if err := os.WriteFile(ini, []byte(content), 0660); err != nil {
+		t.Fatal(err)
+	}
+	log := NewLogger(10).LoadConfig(ini)
+	if n := log.appenders[0].Appender.(*fileLogWriter).MaxDays; n != 7 {
+		t.Fatalf("ini maxday not applied, MaxDays is %d", n)
+	}
+	log.Close()
+}
+
+func TestFileAppenderLevelKey(t *testing.T) {
+	dir := t.TempDir()
+	for _, key := range []string{"level", "Level"} {
+		filename := filepath.Join(dir, key+".log")
+		a := newFileAppender().(*fileLogWriter)
+		if err := a.Init(`{"filename":"` + filename + `","` + key + `":2}`); err != nil {
+			t.Fatal(err)
+		}
+		if a.Level != LevelWarn {
+			t.Fatalf("%s: level is %d, want %d", key, a.Level, LevelWarn)
+		}
+		a.WriteMsg(time.Now(), "[W] kept", LevelWarn)
+		a.WriteMsg(time.Now(), "[I] filtered", LevelInfo)
+		a.Destroy()
+		data, _ := os.ReadFile(filename)
+		if !strings.Contains(string(data), "kept") || strings.Contains(string(data), "filtered") {
+			t.Fatalf("%s: level not applied, got %q", key, data)
+		}
+	}
+}
+
+func BenchmarkFileAppender(b *testing.B) {
+	log := NewLogger(1024)
+	if err := log.SetAppender("file", `{"filename":"`+filepath.Join(b.TempDir(), "bench.log")+`"}`); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info("hello %s", "world")
+	}
+	b.StopTimer()
+	log.Close()
+}
+
+func TestFileAppenderMicroTimestamps(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "micro.log")
+	log := NewLogger(1000)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`"}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Async()
+	for i := 0; i < 500; i++ {
+		log.Info("seq %d", i)
+	}
+	log.Close()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 500 {
+		t.Fatalf("expected 500 lines, got %d", len(lines))
+	}
+	var last time.Time
+	distinct := 0
+	for i, line := range lines {
+		when, err := time.ParseInLocation(TimeLayoutMicro, line[:len(TimeLayoutMicro)], time.Local)
+		if err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		if when.Before(last) {
+			t.Fatalf("timestamp went backwards at line %d: %q", i, line)
+		}
+		if when.After(last) {
+			distinct++
+		}
+		last = when
+		if !strings.HasSuffix(line, "[I] seq "+strconv.Itoa(i)) {
+			t.Fatalf("line %d out of order: %q", i, line)
+		}
+	}
+	if distinct < 2 {
+		t.Fatal("timestamps have no sub-second resolution")
+	}
+}
+
+func TestFileAppenderEscapeNewlines(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "escape.log")
+	log := NewLogger(10)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","escapenewlines":true}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("SELECT *\nFROM t\r\nWHERE id = 1")
+	log.Close()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(data), "\n") != 1 || !strings.HasSuffix(string(data), `[I] SELECT *\nFROM t\r\nWHERE id = 1`+"\n") {
+		t.Fatalf("record not kept on one line: %q", data)
+	}
+}
+
+func TestFileAppenderCallbacks(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "hooks.log")
+	old := filepath.Join(dir, "hooks_2001-01-01.log")
+	if err := os.WriteFile(old, []byte("old\n"), 0660); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-72 * time.Hour)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatal(err)
+	}
+	log := NewLogger(100)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","maxdays":1}`); err != nil {
+		t.Fatal(err)
+	}
+	rotated, deleted, failed := make(chan string, 1), make(chan string, 1), make(chan error, 1)
+	log.OnRotate(func(path string) {
+		rotated <- path
+		panic("recovered by the appender")
+	})
+	log.OnDelete(func(path string) { deleted <- path })
+	log.OnError(func(err error) { failed <- err })
+
+	log.Info("before rotate")
+	if err := log.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	wait := func(what string, ch chan string, want string) {
+		select {
+		case got := <-ch:
+			if got != want {
+				t.Fatalf("%s hook called with %s, want %s", what, got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s hook not called", what)
+		}
+	}
+	wait("rotate", rotated, filepath.Join(dir, "hooks_"+time.Now().Format("2006-01-02")+"_001.log"))
+	wait("delete", deleted, old)
+
+	log.appenders[0].Appender.(*fileLogWriter).fileWriter.Close()
+	log.Info("write to a closed file")
+	select {
+	case err := <-failed:
+		if !strings.Contains(err.Error(), "closed") {
+			t.Fatalf("unexpected error %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("error hook not called")
+	}
+}
+
+func TestFileAppenderHealthCheck(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	if err := os.Mkdir(dir, 0770); err != nil {
+		t.Fatal(err)
+	}
+	log := NewLogger(10)
+	if err := log.SetAppender("file", `{"filename":"`+filepath.Join(dir, "health.log")+`"}`); err != nil {
+		t.Fatal(err)
+	}
+	log.AddAppender("record", &Recorder{})
+	health := log.HealthCheck()
+	if len(health) != 2 || health["file"] != nil || health["record"] != nil {
+		t.Fatalf("expected healthy appenders, got %v", health)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if health := log.HealthCheck(); health["file"] == nil || health["record"] != nil {
+		t.Fatalf("removed log directory not reported, got %v", health)
+	}
+	log.Close()
+}
+
+func TestFileAppenderRotateSuffix(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "weekly.log")
+	log := NewLogger(10)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","rotatesuffix":"{isoweek}"}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("before rotate")
+	if err := log.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	log.Close()
+	year, week := time.Now().ISOWeek()
+	rotated := filepath.Join(dir, "weekly_"+strconv.Itoa(year)+"-W"+fmt.Sprintf("%02d", week)+"_001.log")
+	if _, err := os.Stat(rotated); err != nil {
+		t.Fatalf("rotated file %s not created: %v", rotated, err)
+	}
+	if err := newFileAppender().Init(`{"filename":"` + filename + `","rotatesuffix":"15:04"}`); err == nil {
+		t.Fatal("a suffix with ':' must be refused")
+	}
+}
+
+func TestFileAppenderMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "backups.log")
+	log := NewLogger(10)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","maxbackups":3}`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 5; i++ {
+		log.Info("rotation %d", i)
+		if err := log.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	log.Close()
+
+	var kept []string
+	deadline := time.Now().Add(time.Second)
+	for {
+		kept = kept[:0]
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if entry.Name() == "backups.log" {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err == nil {
+				kept = append(kept, strings.TrimSpace(string(data[strings.Index(string(data), "rotation"):])))
+			}
+		}
+		if len(kept) == 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	sort.Strings(kept)
+	if want := "rotation 3,rotation 4,rotation 5"; strings.Join(kept, ",") != want {
+		t.Fatalf("rotated files kept %q, want the three newest %s", kept, want)
+	}
+}
+
+func TestFileAppenderLineWrap(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "wrap.log")
+	log := NewLogger(10)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","lineprefix":"stdout F ","linesuffix":" <EOL>","timelayout":"15:04 "}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("first")
+	log.Error("second")
+	log.Close()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", data)
+	}
+	for i, want := range []string{"[I] first <EOL>", "[E] second <EOL>"} {
+		if !strings.HasPrefix(lines[i], "stdout F ") || !strings.HasSuffix(lines[i], want) {
+			t.Fatalf("line %q not wrapped", lines[i])
+		}
+	}
+}
+
+func TestFileAppenderLiveCompress(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "live.log.gz")
+	log := NewLogger(10)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","livecompress":true}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("before rotate")
+	if err := log.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		log.Info("line %d", i)
+	}
+	log.Flush()
+	log.Close()
+
+	read := func(name string) []string {
+		file, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("%s is not a valid gzip stream: %v", name, err)
+		}
+		return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+	rotated := read(filepath.Join(dir, "live.log_"+time.Now().Format("2006-01-02")+"_001.gz"))
+	if len(rotated) != 1 || !strings.HasSuffix(rotated[0], "[I] before rotate") {
+		t.Fatalf("rotated file got %q", rotated)
+	}
+	lines := read(filename)
+	if len(lines) != 100 || !strings.HasSuffix(lines[99], "[I] line 99") {
+		t.Fatalf("live file got %d lines, last %q", len(lines), lines[len(lines)-1])
+	}
+}
+
+func TestFileAppenderMaxTotalSize(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "budget.log")
+	log := NewLogger(10)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","maxtotalsize":250}`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 4; i++ {
+		log.Info("rotation %d %s", i, strings.Repeat("x", 80))
+		if err := log.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	log.Close()
+
+	var total int64
+	var kept []string
+	deadline := time.Now().Add(time.Second)
+	for {
+		total, kept = 0, kept[:0]
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if entry.Name() == "budget.log" || err != nil {
+				continue
+			}
+			total += info.Size()
+			data, _ := os.ReadFile(filepath.Join(dir, entry.Name()))
+			kept = append(kept, string(data[strings.Index(string(data), "rotation"):][:len("rotation 1")]))
+		}
+		if len(kept) == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	sort.Strings(kept)
+	if total > 250 || strings.Join(kept, ",") != "rotation 3,rotation 4" {
+		t.Fatalf("kept %q with %d bytes, want the two newest under 250 bytes", kept, total)
+	}
+}
+
+func TestFileAppenderControl(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "control.log")
+	log := NewLogger(10)
+	defer log.Close()
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","maxsize":1048576,"daily":false}`); err != nil {
+		t.Fatal(err)
+	}
+	count := func() int {
+		entries, _ := os.ReadDir(dir)
+		return len(entries)
+	}
+	for i := 0; i < 10; i++ {
+		log.Info("before %d", i)
+	}
+	if n := count(); n != 1 {
+		t.Fatalf("%d files before lowering maxsize", n)
+	}
+	control, err := log.FileControl("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	control.SetMaxSize(100)
+	for i := 0; i < 10; i++ {
+		log.Info("after %d", i)
+	}
+	if n := count(); n < 3 {
+		t.Fatalf("%d files after lowering maxsize, rotation did not trigger", n)
+	}
+	if _, err := log.FileControl("missing"); err == nil {
+		t.Fatal("FileControl of a missing appender should fail")
+	}
+}
+
+func TestFileAppenderOSync(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "osync.log")
+	log := NewLogger(10)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","osync":true}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("durable")
+	//best effort check of the open flags, linux only
+	fd := log.appenders[0].Appender.(*fileLogWriter).fileWriter.Fd()
+	if info, err := os.ReadFile("/proc/self/fdinfo/" + strconv.Itoa(int(fd))); err == nil {
+		for _, line := range strings.Split(string(info), "\n") {
+			if !strings.HasPrefix(line, "flags:") {
+				continue
+			}
+			flags, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(line, "flags:")), 8, 64)
+			if err == nil && int(flags)&os.O_SYNC != os.O_SYNC {
+				t.Fatalf("file opened without O_SYNC: %s", line)
+			}
+		}
+	}
+	log.Close()
+	data, err := os.ReadFile(filename)
+	if err != nil || !strings.HasSuffix(string(data), "[I] durable\n") {
+		t.Fatalf("got %q %v", data, err)
+	}
+}
+
+func TestFileAppenderLineEnding(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "crlf.log")
+	log := NewLogger(10)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","lineending":"crlf"}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("first")
+	log.Info("second")
+	log.Close()
+	data, _ := os.ReadFile(filename)
+	if lines := strings.SplitAfter(string(data), "\n"); len(lines) != 3 ||
+		!strings.HasSuffix(lines[0], "first\r\n") || !strings.HasSuffix(lines[1], "second\r\n") {
+		t.Fatalf("got %q", data)
+	}
+	if err := NewLogger(10).SetAppender("file", `{"filename":"`+filename+`","lineending":"cr"}`); err == nil {
+		t.Fatal("unknown lineending must fail")
+	}
+}
+
+func TestFileAppenderDeletesOnlyRotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	a, err := newAppender("file", `{"filename":"`+filepath.Join(dir, "app.log")+`","maxdays":1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Destroy()
+	deleted := []string{"app_2001-01-01.log", "app_2001-01-01_002.log"}
+	kept := []string{"app.log", "app_old-config.log", "app_2001-01-01.log.bak", "apps_2001-01-01.log", "app-2001-01-01.log"}
+	past := time.Now().Add(-72 * time.Hour)
+	for _, name := range append(append([]string(nil), deleted...), kept...) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("old\n"), 0660); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.(*fileLogWriter).deleteOldLog(nil, 1)
+	for _, name := range deleted {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("old rotated file %s kept", name)
+		}
+	}
+	for _, name := range kept {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s is not a rotated file but was deleted", name)
+		}
+	}
+}
+
+func TestRotatedPattern(t *testing.T) {
+	host, _ := os.Hostname()
+	for _, c := range []struct {
+		layout, name string
+		match        bool
+	}{
+		{"2006-01-02", "app_2024-03-09.log", true},
+		{"2006-01-02", "app_2024-03-09_012.log", true},
+		{"2006-01-02", "app_2024-03-09_12.log", false},
+		{"2006-01-02", "app_config.log", false},
+		{"2006-01-02_15", "app_2024-03-09_07.log", true},
+		{"Jan-02", "app_Mar-09.log", true},
+		{"{isoweek}", "app_2024-W10.log", true},
+		{"{isoweek}", "app_2024-10.log", false},
+		{"{host}-2006", "app_" + host + "-2024.log", true},
+		{"{host}-2006", "app_otherhost-2024.log", host == "otherhost"},
+	} {
+		if got := rotatedPattern("app", c.layout, ".log").MatchString(c.name); got != c.match {
+			t.Errorf("rotatesuffix %q: %s matched %v, want %v", c.layout, c.name, got, c.match)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package logg
+
+import "regexp"
+
+//SetIncludeFilter drop the messages whose text does not match re, nil keeps
+//them all. Applied before the exclude filter: a message passes when it matches
+//the include filter and does not match the exclude filter
+func (log *BaseLogger) SetIncludeFilter(re *regexp.Regexp) {
+	log.includeFilter.Store(re)
+}
+
+//SetExcludeFilter drop the messages whose text matches re, nil drops none
+func (log *BaseLogger) SetExcludeFilter(re *regexp.Regexp) {
+	log.excludeFilter.Store(re)
+}
+
+//filtered report whether msg is dropped by the include or exclude filter
+func (log *BaseLogger) filtered(msg string) bool {
+	if re, _ := log.includeFilter.Load().(*regexp.Regexp); re != nil && !re.MatchString(msg) {
+		return true
+	}
+	if re, _ := log.excludeFilter.Load().(*regexp.Regexp); re != nil && re.MatchString(msg) {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package logg
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMessageFilters(t *testing.T) {
+	log := NewLogger(10)
+	r := addRecorder(log, "record")
+	log.SetIncludeFilter(regexp.MustCompile(`^db `))
+	log.SetExcludeFilter(regexp.MustCompile(`ping`))
+	log.Info("db query")
+	log.Info("db ping")
+	log.Info("http request")
+	log.SetIncludeFilter(nil)
+	log.Info("http request")
+	log.Info("http ping")
+	log.SetExcludeFilter(nil)
+	log.Info("http ping")
+	want := []string{"db query", "http request", "http ping"}
+	lines := r.Lines()
+	if len(lines) != len(want) {
+		t.Fatalf("got %q, want %q", lines, want)
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, want[i]) {
+			t.Fatalf("got %q, want %q", lines, want)
+		}
+	}
+}
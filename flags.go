@@ -0,0 +1,51 @@
+package logg
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+)
+
+//logFlags the values of the RegisterFlags flags
+type logFlags struct {
+	level string
+	file  string
+	color bool
+}
+
+//RegisterFlags add -loglevel, -logfile and -logcolor to fs, applied by
+//ConfigureFromFlags after fs.Parse
+func (log *BaseLogger) RegisterFlags(fs *flag.FlagSet) {
+	f := &logFlags{}
+	fs.StringVar(&f.level, "loglevel", "", "log level: fatal, error, warn, info or debug")
+	fs.StringVar(&f.file, "logfile", "", "log to this file instead of the console")
+	fs.BoolVar(&f.color, "logcolor", true, "color the console log")
+	log.flags = f
+}
+
+//ConfigureFromFlags apply the flags of RegisterFlags: set the level when
+//-loglevel is given and add a file appender for -logfile, else a console
+//appender colored by -logcolor
+func (log *BaseLogger) ConfigureFromFlags() error {
+	f := log.flags
+	if f == nil {
+		return errors.New("logg: ConfigureFromFlags called before RegisterFlags")
+	}
+	if len(f.level) > 0 {
+		if err := log.SetLevelString(f.level); err != nil {
+			return err
+		}
+	}
+	if len(f.file) > 0 {
+		config, err := json.Marshal(map[string]interface{}{"filename": f.file})
+		if err != nil {
+			return err
+		}
+		return log.SetAppender("file", string(config))
+	}
+	config, err := json.Marshal(map[string]interface{}{"color": f.color})
+	if err != nil {
+		return err
+	}
+	return log.SetAppender("console", string(config))
+}
@@ -0,0 +1,60 @@
+package logg
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigureFromFlags(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "flags.log")
+	log := NewLogger(10)
+	fs := flag.NewFlagSet("tool", flag.ContinueOnError)
+	log.RegisterFlags(fs)
+	if err := fs.Parse([]string{"-loglevel", "warn", "-logfile", filename}); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.ConfigureFromFlags(); err != nil {
+		t.Fatal(err)
+	}
+	if log.Level() != LevelWarn {
+		t.Fatalf("level %d", log.Level())
+	}
+	log.Info("filtered")
+	log.Warn("to the file")
+	log.Close()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); strings.Count(got, "\n") != 1 || !strings.Contains(got, "[W] to the file") {
+		t.Fatalf("file got %q", got)
+	}
+
+	log = NewLogger(10)
+	fs = flag.NewFlagSet("tool", flag.ContinueOnError)
+	log.RegisterFlags(fs)
+	if err := fs.Parse([]string{"-logcolor=false"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.ConfigureFromFlags(); err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	if log.Level() != LevelDebug || len(log.appenders) != 1 || log.appenders[0].name != "console" {
+		t.Fatalf("level %d, appenders %d", log.Level(), len(log.appenders))
+	}
+	if c := log.appenders[0].Appender.(*consoleWriter); c.Colorful {
+		t.Fatal("-logcolor=false kept the color")
+	}
+
+	fs.Parse([]string{"-loglevel", "loud"})
+	if err := log.ConfigureFromFlags(); err == nil {
+		t.Fatal("want error for an unknown level")
+	}
+	if err := NewLogger(10).ConfigureFromFlags(); err == nil {
+		t.Fatal("want error without RegisterFlags")
+	}
+}
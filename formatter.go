@@ -0,0 +1,400 @@
+package logg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var levelLabels = []string{"[F]", "[E]", "[W]", "[I]", "[D]"}
+
+var levelNames = []string{"fatal", "error", "warn", "info", "debug"}
+
+//TimeLayoutMicro the default "timelayout" of the text appenders, with
+//microseconds so fast messages keep their order
+const TimeLayoutMicro = "2006-01-02 15:04:05.000000"
+
+const timeLayout = TimeLayoutMicro
+
+//Entry one log message handed to appenders and formatters
+type Entry struct {
+	When   time.Time
+	Level  int
+	Msg    string
+	Caller string
+	//Func the calling function like "logg.TestX", only set with EnableCallerFunc
+	Func   string
+	Fields []Field
+	//Version the tag of SetVersionTag, rendered as {v=1.4.2} at the end of the text
+	Version string
+	//Seq the number of SetSequence, rendered as #0000123 at the start of the text
+	Seq uint64
+	//Goroutine id of the caller, only set when an appender uses "includegoroutine"
+	Goroutine uint64
+	origin    *BaseLogger   //logger the message was logged with
+	via       []*BaseLogger //loggers it was forwarded to by tee appenders
+	stamp     []byte
+	stampBuf  [32]byte
+	text      string
+}
+
+//Stamp the timestamp of the text output, rendered once and shared by appenders
+func (e *Entry) Stamp() string {
+	return string(e.appendStamp(nil, timeLayout))
+}
+
+//appendStamp append When rendered with layout, the default layout is cached
+func (e *Entry) appendStamp(dst []byte, layout string) []byte {
+	if layout != timeLayout {
+		return e.When.AppendFormat(dst, layout)
+	}
+	if e.stamp == nil {
+		e.stamp = e.When.AppendFormat(e.stampBuf[:0], timeLayout)
+	}
+	return append(dst, e.stamp...)
+}
+
+//Text render the entry without timestamp, like `[I][file.go:12] msg key=value`,
+//rendered once and shared by appenders
+func (e *Entry) Text() string {
+	if len(e.text) == 0 {
+		e.text = string(e.appendText(nil))
+	}
+	return e.text
+}
+
+//FormatForTest render msg like the text appenders but with the zero time,
+//a stable output for golden tests
+func FormatForTest(level int, msg string) string {
+	e := &Entry{Level: level, Msg: msg}
+	return e.Stamp() + e.Text()
+}
+
+func (e *Entry) appendText(dst []byte) []byte {
+	if len(e.text) > 0 {
+		return append(dst, e.text...)
+	}
+	if e.Seq > 0 {
+		dst = append(dst, '#')
+		for n := uint64(1000000); e.Seq < n; n /= 10 {
+			dst = append(dst, '0')
+		}
+		dst = strconv.AppendUint(dst, e.Seq, 10)
+		dst = append(dst, ' ')
+	}
+	dst = append(dst, levelLabels[e.Level]...)
+	if len(e.Caller) > 0 {
+		dst = append(dst, '[')
+		dst = append(dst, e.Caller...)
+		dst = append(dst, ']')
+	}
+	dst = append(dst, ' ')
+	dst = append(dst, e.Msg...)
+	if len(e.Fields) > 0 {
+		buf := bytes.NewBuffer(dst)
+		writeTextFields(buf, "", e.Fields)
+		dst = buf.Bytes()
+	}
+	if len(e.Version) > 0 {
+		dst = append(dst, " {v="...)
+		dst = append(dst, e.Version...)
+		dst = append(dst, '}')
+	}
+	return dst
+}
+
+//textValue render times as RFC3339 in the text output, durations keep
+//their String like 1.5s
+func textValue(v interface{}) interface{} {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	return v
+}
+
+//fieldFormat how a structured formatter renders the builtin keys and the
+//duration and time fields
+type fieldFormat struct {
+	fieldKeys
+	durationUnit time.Duration //durations are numbers in this unit
+	timeLayout   string
+}
+
+var defaultFieldFormat = fieldFormat{defaultFieldKeys, time.Millisecond, time.RFC3339}
+
+//orDefault f, or the default format for a nil f
+func (f *fieldFormat) orDefault() *fieldFormat {
+	if f == nil {
+		return &defaultFieldFormat
+	}
+	return f
+}
+
+//value render durations as numbers and times with the layout, also inside
+//slices and maps
+func (f *fieldFormat) value(v interface{}) interface{} {
+	switch t := v.(type) {
+	case time.Duration:
+		return float64(t) / float64(f.durationUnit)
+	case time.Time:
+		return t.Format(f.timeLayout)
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, elem := range t {
+			out[i] = f.value(elem)
+		}
+		return out
+	case map[string]interface{}:
+		if t == nil {
+			return t
+		}
+		out := make(map[string]interface{}, len(t))
+		for key, elem := range t {
+			out[key] = f.value(elem)
+		}
+		return out
+	}
+	return v
+}
+
+//fieldFormatter the settings shared by the structured formatters
+type fieldFormatter struct {
+	format *fieldFormat
+}
+
+//SetFieldFormat render duration fields as numbers of durationUnit and time
+//fields with timeLayout, milliseconds and RFC3339 by default. Zero values keep
+//the current setting. The "durationunit" and "fieldtimelayout" appender
+//options call it
+func (f *fieldFormatter) SetFieldFormat(durationUnit time.Duration, timeLayout string) error {
+	if durationUnit < 0 {
+		return errors.New("logg: negative duration unit " + durationUnit.String())
+	}
+	format := *f.format.orDefault()
+	if durationUnit > 0 {
+		format.durationUnit = durationUnit
+	}
+	if len(timeLayout) > 0 {
+		format.timeLayout = timeLayout
+	}
+	f.format = &format
+	return nil
+}
+
+//setFieldKeys SetFieldKeys of the formatters having the builtin keys
+func (f *fieldFormatter) setFieldKeys(time, level, msg string) error {
+	keys, err := newFieldKeys(time, level, msg)
+	if err != nil {
+		return err
+	}
+	format := *f.format.orDefault()
+	format.fieldKeys = *keys
+	f.format = &format
+	return nil
+}
+
+func writeTextFields(buf *bytes.Buffer, prefix string, fields []Field) {
+	for _, f := range fields {
+		key := f.Key
+		if len(prefix) > 0 && len(key) > 0 {
+			key = prefix + "." + key
+		} else if len(key) == 0 {
+			key = prefix
+		}
+		group, ok := f.Value.([]Field)
+		if !ok {
+			if group, ok = expandFields(f.Value, 1); ok && len(group) == 0 && len(key) > 0 {
+				fmt.Fprintf(buf, " %s=%v", key, emptyObject{})
+				continue
+			}
+		}
+		if ok {
+			writeTextFields(buf, key, group)
+			continue
+		}
+		fmt.Fprintf(buf, " %s=%v", key, textValue(f.Value))
+	}
+}
+
+//Formatter render an entry into the bytes an appender writes, without line ending
+type Formatter interface {
+	Format(e *Entry) []byte
+}
+
+type createFormatter func() Formatter
+
+var formatterMap = make(map[string]createFormatter)
+
+//RegisterFormatter register a formatter selectable by appenders' "format" config,
+//each appender creating its own so appenders of one logger render differently
+func RegisterFormatter(name string, formatter createFormatter) {
+	if formatter == nil {
+		panic("logg: RegisterFormatter formatter is nil")
+	}
+	if _, dup := formatterMap[name]; dup {
+		panic("logg:RegisterFormatter called twice for formatter " + name)
+	}
+	formatterMap[name] = formatter
+}
+
+//newFormatter return nil for the classic text output
+func newFormatter(name string, opts formatOptions) (Formatter, error) {
+	var f Formatter
+	if len(name) > 0 && name != "text" {
+		formatter, ok := formatterMap[name]
+		if !ok {
+			return nil, errors.New("logg:unknow format " + name + " (forgotten RegisterFormatter?)")
+		}
+		f = formatter()
+	}
+	if len(opts.TimeKey) > 0 || len(opts.LevelKey) > 0 || len(opts.MsgKey) > 0 {
+		keyer, ok := f.(fieldKeyer)
+		if !ok {
+			return nil, errors.New("logg: format " + strconv.Quote(name) + " has no timekey, levelkey or msgkey")
+		}
+		if err := keyer.SetFieldKeys(opts.TimeKey, opts.LevelKey, opts.MsgKey); err != nil {
+			return nil, err
+		}
+	}
+	if len(opts.DurationUnit) > 0 || len(opts.FieldTimeLayout) > 0 {
+		setter, ok := f.(fieldFormatSetter)
+		if !ok {
+			return nil, errors.New("logg: format " + strconv.Quote(name) + " has no durationunit or fieldtimelayout")
+		}
+		var unit time.Duration
+		if len(opts.DurationUnit) > 0 {
+			var err error
+			if unit, err = time.ParseDuration("1" + opts.DurationUnit); err != nil {
+				return nil, errors.New("logg: unknow durationunit " + opts.DurationUnit)
+			}
+		}
+		if err := setter.SetFieldFormat(unit, opts.FieldTimeLayout); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+//fieldObject fields merged by key in first seen order, groups are *fieldObject.
+//Keys repeated in the same object keep their first position and the last value,
+//except two groups which are merged.
+type fieldObject struct {
+	keys   []string
+	values map[string]interface{}
+	format *fieldFormat //of the values added, the default one when nil
+}
+
+func (o *fieldObject) set(key string, value interface{}) {
+	if o.values == nil {
+		o.values = make(map[string]interface{})
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *fieldObject) add(fields []Field) {
+	for _, f := range fields {
+		group, ok := f.Value.([]Field)
+		if !ok {
+			if group, ok = expandFields(f.Value, 1); ok && len(group) == 0 && len(f.Key) > 0 {
+				o.set(f.Key, emptyObject{})
+				continue
+			}
+		}
+		if !ok {
+			o.set(f.Key, o.format.orDefault().value(f.Value))
+			continue
+		}
+		if len(f.Key) == 0 {
+			o.add(group)
+			continue
+		}
+		sub, ok := o.values[f.Key].(*fieldObject)
+		if !ok {
+			sub = &fieldObject{format: o.format}
+		}
+		sub.add(group)
+		if len(sub.keys) > 0 {
+			o.set(f.Key, sub)
+		}
+	}
+}
+
+var builtinKeys = map[string]bool{"caller": true, "func": true, "version": true, "seq": true}
+
+//fieldKeys the time, level and msg keys of the json and logfmt output
+type fieldKeys struct {
+	time, level, msg string
+}
+
+var defaultFieldKeys = fieldKeys{"time", "level", "msg"}
+
+//newFieldKeys the keys named time, level and msg, an empty name keeps the
+//default one. The names must differ from each other and the other builtin keys
+func newFieldKeys(time, level, msg string) (*fieldKeys, error) {
+	k := defaultFieldKeys
+	names := []struct {
+		dst  *string
+		name string
+	}{{&k.time, time}, {&k.level, level}, {&k.msg, msg}}
+	for _, n := range names {
+		if len(n.name) == 0 {
+			continue
+		}
+		if *n.dst = strings.TrimSpace(n.name); len(*n.dst) == 0 {
+			return nil, errors.New("logg: blank field key " + strconv.Quote(n.name))
+		}
+	}
+	if k.time == k.level || k.time == k.msg || k.level == k.msg || builtinKeys[k.time] || builtinKeys[k.level] || builtinKeys[k.msg] {
+		return nil, errors.New("logg: duplicate field keys " + k.time + ", " + k.level + ", " + k.msg)
+	}
+	return &k, nil
+}
+
+//builtin report whether a top level field key clashes with a builtin key
+func (k *fieldKeys) builtin(key string) bool {
+	return builtinKeys[key] || key == k.time || key == k.level || key == k.msg
+}
+
+//fieldKeyer a formatter whose keys can be renamed
+type fieldKeyer interface {
+	SetFieldKeys(time, level, msg string) error
+}
+
+//fieldFormatSetter a formatter rendering durations and times as configured
+type fieldFormatSetter interface {
+	SetFieldFormat(durationUnit time.Duration, timeLayout string) error
+}
+
+//formatOptions the "timekey", "levelkey" and "msgkey" appender options
+//renaming the keys of the json and logfmt formats, "durationunit" like "ms"
+//or "s" and "fieldtimelayout" rendering the fields of the json, logfmt and
+//ecs formats
+type formatOptions struct {
+	TimeKey         string `json:"timekey"`
+	LevelKey        string `json:"levelkey"`
+	MsgKey          string `json:"msgkey"`
+	DurationUnit    string `json:"durationunit"`
+	FieldTimeLayout string `json:"fieldtimelayout"`
+}
+
+//userFields merge fields into one object, top level keys clashing with the
+//builtin keys are renamed "fields.<key>"
+func userFields(fields []Field, format *fieldFormat) *fieldObject {
+	merged := &fieldObject{format: format}
+	merged.add(fields)
+	obj := &fieldObject{}
+	for _, key := range merged.keys {
+		if format.orDefault().builtin(key) {
+			obj.set("fields."+key, merged.values[key])
+		} else {
+			obj.set(key, merged.values[key])
+		}
+	}
+	return obj
+}
@@ -0,0 +1,287 @@
+package logg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func formatJSON(l *FieldLogger) string {
+	when := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	e := &Entry{When: when, Level: LevelInfo, Msg: "hello", Fields: l.fields}
+	return string((&JSONFormatter{}).Format(e))
+}
+
+func TestStructuredSubSecondTime(t *testing.T) {
+	e := &Entry{When: time.Date(2020, 1, 2, 3, 4, 5, 123456789, time.UTC), Level: LevelInfo, Msg: "m"}
+	if got := string((&JSONFormatter{}).Format(e)); !strings.HasPrefix(got, `{"time":"2020-01-02T03:04:05.123456789Z",`) {
+		t.Fatalf("json got %s", got)
+	}
+	if got := string((&LogfmtFormatter{}).Format(e)); !strings.HasPrefix(got, "time=2020-01-02T03:04:05.123456789Z ") {
+		t.Fatalf("logfmt got %s", got)
+	}
+}
+
+func TestJSONFormatterGroup(t *testing.T) {
+	log := NewLogger(10)
+	got := formatJSON(log.WithGroup("req").With(Field{"id", 7}, Field{"path", "/a"}))
+	want := `{"time":"2020-01-02T03:04:05Z","level":"info","msg":"hello","req":{"id":7,"path":"/a"}}`
+	if got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestJSONFormatterNestedGroup(t *testing.T) {
+	log := NewLogger(10)
+	l := log.WithGroup("req").With(Field{"id", 7}).WithGroup("user").With(Field{"name", "bob"})
+	got := formatJSON(l.With(Group("", Field{"admin", true})))
+	want := `{"time":"2020-01-02T03:04:05Z","level":"info","msg":"hello","req":{"id":7,"user":{"name":"bob","admin":true}}}`
+	if got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestJSONFormatterDuplicateKey(t *testing.T) {
+	log := NewLogger(10)
+	l := log.With(Field{"id", 1}, Field{"msg", "field"}).WithGroup("req").With(Field{"id", 2})
+	l = l.WithGroup("req").With(Field{"id", 3})
+	got := formatJSON(log.With(l.fields...).With(Group("req", Field{"id", 4}, Field{"ok", true})))
+	want := `{"time":"2020-01-02T03:04:05Z","level":"info","msg":"hello","id":1,"fields.msg":"field","req":{"id":4,"req":{"id":3},"ok":true}}`
+	if got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestJSONFileAppender(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "json.log")
+	log := NewLogger(10)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","format":"json"}`); err != nil {
+		t.Fatal(err)
+	}
+	log.WithGroup("req").With(Field{"id", 7}).Warn("hello %s", "json")
+	log.Close()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := strings.TrimSuffix(string(data), "\n")
+	if !strings.HasPrefix(line, `{"time":"`) || !strings.HasSuffix(line, `"level":"warn","msg":"hello json","req":{"id":7}}`) {
+		t.Fatalf("unexpected json line %q", data)
+	}
+}
+
+func TestFormatPerAppender(t *testing.T) {
+	dir := t.TempDir()
+	log := NewLogger(10)
+	if err := log.SetAppender("file", `{"filename":"`+filepath.Join(dir, "json.log")+`","format":"json"}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.SetAppender("file", `{"filename":"`+filepath.Join(dir, "logfmt.log")+`","format":"logfmt"}`); err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	c := newConsoleAppender().(*consoleWriter)
+	c.lg = newLogWriter(&buf)
+	if err := c.Init(`{"color":true,"notime":true}`); err != nil {
+		t.Fatal(err)
+	}
+	log.AddAppender("console", c)
+	log.With(Int("id", 7)).Info("event")
+	log.Close()
+	for name, want := range map[string]string{
+		"json.log":   `"level":"info","msg":"event","id":7}`,
+		"logfmt.log": ` level=info msg=event id=7`,
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(strings.TrimSuffix(string(data), "\n"), want) {
+			t.Fatalf("%s got %q, want suffix %q", name, data, want)
+		}
+	}
+	if got := buf.String(); !strings.HasPrefix(got, "\x1b[") || !strings.Contains(got, "[I] event id=7") {
+		t.Fatalf("console got %q", got)
+	}
+}
+
+func TestSetFieldKeys(t *testing.T) {
+	e := &Entry{When: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Level: LevelInfo, Msg: "hello",
+		Fields: []Field{{"msg", "mine"}, {"message", "clash"}}}
+	j := &JSONFormatter{}
+	if err := j.SetFieldKeys("@timestamp", "", " message "); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"@timestamp":"2020-01-02T03:04:05Z","level":"info","message":"hello","msg":"mine","fields.message":"clash"}`
+	if got := string(j.Format(e)); got != want {
+		t.Fatalf("json got %s, want %s", got, want)
+	}
+	if got := string((&JSONFormatter{}).Format(e)); !strings.HasPrefix(got, `{"time":`) {
+		t.Fatalf("another formatter renamed too: %s", got)
+	}
+	l := &LogfmtFormatter{}
+	if err := l.SetFieldKeys("@timestamp", "", "message"); err != nil {
+		t.Fatal(err)
+	}
+	want = `@timestamp=2020-01-02T03:04:05Z level=info message=hello msg=mine fields.message=clash`
+	if got := string(l.Format(e)); got != want {
+		t.Fatalf("logfmt got %s, want %s", got, want)
+	}
+	for _, keys := range [][3]string{{"", "msg", ""}, {"t", "t", ""}, {"", "", "caller"}, {" ", "", ""}} {
+		if err := j.SetFieldKeys(keys[0], keys[1], keys[2]); err == nil {
+			t.Fatalf("want error for keys %q", keys)
+		}
+	}
+
+	var buf strings.Builder
+	c := newConsoleAppender().(*consoleWriter)
+	c.lg = newLogWriter(&buf)
+	if err := c.Init(`{"format":"json","timekey":"@timestamp","msgkey":"message"}`); err != nil {
+		t.Fatal(err)
+	}
+	c.WriteEntry(e)
+	if got := buf.String(); !strings.HasPrefix(got, `{"@timestamp":"2020-01-02T03:04:05Z","level":"info","message":"hello"`) {
+		t.Fatalf("console got %q", got)
+	}
+	if err := newConsoleAppender().Init(`{"msgkey":"message"}`); err == nil {
+		t.Fatal("want error for msgkey on the text format")
+	}
+}
+
+func TestJSONCallerFunc(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "caller.log")
+	log := NewLogger(10)
+	log.EnableFuncCallDepath(true)
+	log.EnableCallerFunc(true)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","format":"json"}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("hello")
+	log.Close()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(`"caller":"formatter_test\.go:[0-9]+","func":"logg\.TestJSONCallerFunc"`)
+	if !re.Match(data) {
+		t.Fatalf("unexpected json line %q", data)
+	}
+}
+
+func TestFormatForTest(t *testing.T) {
+	got := FormatForTest(LevelError, "disk full")
+	if got != "0001-01-01 00:00:00.000000[E] disk full" {
+		t.Fatalf("unexpected golden output %q", got)
+	}
+	time.Sleep(time.Millisecond)
+	if again := FormatForTest(LevelError, "disk full"); again != got {
+		t.Fatalf("output depends on wall clock: %q vs %q", again, got)
+	}
+}
+
+func TestDurationAndTimeFields(t *testing.T) {
+	at := time.Date(2021, 5, 6, 7, 8, 9, 0, time.UTC)
+	fields := []Field{{"took", 1500 * time.Millisecond}, {"wait", 1500 * time.Microsecond}, {"at", at},
+		{"list", []interface{}{time.Second}}}
+	e := &Entry{When: at, Level: LevelInfo, Msg: "done", Fields: fields}
+	if got := e.Text(); got != "[I] done took=1.5s wait=1.5ms at=2021-05-06T07:08:09Z list=[1s]" {
+		t.Fatalf("text got %q", got)
+	}
+	want := `{"time":"2021-05-06T07:08:09Z","level":"info","msg":"done","took":1500,"wait":1.5,"at":"2021-05-06T07:08:09Z","list":[1000]}`
+	if got := string((&JSONFormatter{}).Format(e)); got != want {
+		t.Fatalf("json got %s\nwant %s", got, want)
+	}
+
+	var buf strings.Builder
+	c := newConsoleAppender().(*consoleWriter)
+	c.lg = newLogWriter(&buf)
+	if err := c.Init(`{"format":"logfmt","durationunit":"s","fieldtimelayout":"2006-01-02"}`); err != nil {
+		t.Fatal(err)
+	}
+	c.WriteEntry(e)
+	if got := buf.String(); !strings.HasSuffix(got, " took=1.5 wait=0.0015 at=2021-05-06 list=[1]\n") {
+		t.Fatalf("logfmt with custom unit got %q", got)
+	}
+	if got := string((&LogfmtFormatter{}).Format(e)); !strings.Contains(got, " took=1500 ") {
+		t.Fatalf("another formatter changed too: %s", got)
+	}
+	for _, config := range []string{`{"format":"json","durationunit":"parsec"}`, `{"durationunit":"s"}`} {
+		if err := newConsoleAppender().Init(config); err == nil {
+			t.Fatalf("want error for %s", config)
+		}
+	}
+}
+
+func TestJSONFormatterNativeTypes(t *testing.T) {
+	log := NewLogger(10)
+	got := formatJSON(log.With(Field{"count", 5}, Field{"ok", true}, Field{"ratio", 1.5},
+		Field{"tags", []string{"a", "b"}}, Field{"none", nil}, Field{"id", uint64(7)},
+		Field{"meta", map[string]interface{}{"b": []interface{}{1, "x", nil}, "a": errors.New("boom")}}))
+	want := `{"time":"2020-01-02T03:04:05Z","level":"info","msg":"hello","count":5,"ok":true,"ratio":1.5,` +
+		`"tags":["a","b"],"none":null,"id":7,"meta":{"a":"boom","b":[1,"x",null]}}`
+	if got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+}
+
+type testAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type TestBase struct {
+	ID int `json:"id"`
+}
+
+type testUser struct {
+	TestBase
+	Name    string       `json:"name"`
+	Secret  string       `json:"-"`
+	Address *testAddress `json:"address"`
+	Tags    []string     `json:"tags,omitempty"`
+	Since   time.Time    `json:"since"`
+	note    string
+	Parent  *testUser `json:"parent,omitempty"`
+}
+
+func TestReflectedFields(t *testing.T) {
+	log := NewLogger(10)
+	r := addRecorder(log, "record")
+	since := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	user := &testUser{TestBase: TestBase{1}, Name: "bob", Secret: "x", Address: &testAddress{City: "Paris"}, Since: since, note: "n"}
+	meta := map[string]interface{}{"b": map[int]bool{2: true}, "a": 1}
+	got := formatJSON(log.With(Field{"user", user}, Field{"meta", meta}))
+	want := `{"time":"2020-01-02T03:04:05Z","level":"info","msg":"hello","user":{"id":1,"name":"bob",` +
+		`"address":{"city":"Paris"},"since":"2020-01-02T00:00:00Z"},"meta":{"a":1,"b":{"2":true}}}`
+	if got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+	log.Infow("text", "meta", meta, "addr", testAddress{"Rome", "00100"})
+	if lines := r.Lines(); len(lines) != 1 || lines[0] != "[I] text meta.a=1 meta.b.2=true addr.city=Rome addr.zip=00100" {
+		t.Fatalf("text got %q", lines)
+	}
+
+	var nilMap map[string]int
+	got = formatJSON(log.With(Field{"empty", map[string]int{}}, Field{"none", nilMap},
+		Field{"nested", map[string]interface{}{"e": map[string]bool{}, "n": map[string]interface{}(nil)}}))
+	if !strings.HasSuffix(got, `"empty":{},"none":null,"nested":{"e":{},"n":null}}`) {
+		t.Fatalf("empty and nil maps got %s", got)
+	}
+
+	//a cycle stops at FieldMaxDepth
+	user.Parent = user
+	got = formatJSON(log.With(Field{"user", user}))
+	if depth := strings.Count(got, `"parent":`); depth != FieldMaxDepth-1 {
+		t.Fatalf("cycle expanded %d levels: %s", depth, got)
+	}
+	loop := map[string]interface{}{}
+	loop["self"] = loop
+	if got := formatJSON(log.With(Field{"loop", loop})); strings.Count(got, `"self":`) != FieldMaxDepth-1 ||
+		!strings.Contains(got, `"self":"…"`) {
+		t.Fatalf("map cycle got %s", got)
+	}
+}
@@ -0,0 +1,226 @@
+package logg
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+//syslogSeverities the syslog severity of each level
+var syslogSeverities = []int{
+	2, //LevelFatal crit
+	3, //LevelError err
+	4, //LevelWarn warning
+	6, //LevelInfo info
+	7, //LevelDebug debug
+}
+
+const (
+	gelfChunkHeader = 12
+	gelfMaxChunks   = 128
+)
+
+var gelfFieldName = regexp.MustCompile(`[^\w.\-]`)
+
+//gelfWriter send GELF 1.1 messages to Graylog over udp, chunked when larger
+//than "chunksize", or over tcp, null delimited
+type gelfWriter struct {
+	sync.Mutex
+	Address   string `json:"address"`  //host:port of the GELF input
+	Protocol  string `json:"protocol"` //udp (default) or tcp
+	Host      string `json:"host"`     //the hostname by default
+	ChunkSize int    `json:"chunksize"`
+	Level     int    `json:"level"`
+	MaxLevel  int    `json:"maxlevel"`
+	conn      net.Conn
+	buf       bytes.Buffer
+}
+
+func newGelfAppender() Appender {
+	host, _ := os.Hostname()
+	return &gelfWriter{
+		Protocol:  "udp",
+		Host:      host,
+		ChunkSize: 1420,
+		Level:     LevelDebug,
+	}
+}
+
+//Init config like
+//{
+//"address":"graylog:12201",
+//"protocol":"udp",
+//"host":"web-1",
+//"chunksize":1420,
+//"level":4,
+//}
+func (g *gelfWriter) Init(config string) error {
+	if err := json.Unmarshal([]byte(config), g); err != nil {
+		return err
+	}
+	if len(g.Address) == 0 {
+		return errors.New("logg: gelf appender config must have address")
+	}
+	switch g.Protocol {
+	case "udp", "tcp":
+	default:
+		return errors.New("logg: unknow gelf protocol " + g.Protocol)
+	}
+	if g.ChunkSize <= gelfChunkHeader {
+		return errors.New("logg: gelf chunksize too small")
+	}
+	return g.dial()
+}
+
+func (g *gelfWriter) dial() error {
+	conn, err := net.DialTimeout(g.Protocol, g.Address, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	g.conn = conn
+	return nil
+}
+
+func (g *gelfWriter) WriteMsg(when time.Time, msg string, level int) error {
+	return g.WriteEntry(&Entry{When: when, Level: level, Msg: msg})
+}
+
+func (g *gelfWriter) WriteEntry(e *Entry) error {
+	if e.Level > g.Level || e.Level < g.MaxLevel {
+		return nil
+	}
+	g.Lock()
+	defer g.Unlock()
+	if g.conn == nil {
+		if err := g.dial(); err != nil {
+			return err
+		}
+	}
+	g.buf.Reset()
+	appendGelf(&g.buf, e, g.Host)
+	var err error
+	if g.Protocol == "tcp" {
+		g.buf.WriteByte(0)
+		_, err = g.conn.Write(g.buf.Bytes())
+	} else {
+		err = g.writeChunked(g.buf.Bytes())
+	}
+	if err != nil && g.Protocol == "tcp" {
+		//reconnect at the next message
+		g.conn.Close()
+		g.conn = nil
+	}
+	return err
+}
+
+//writeChunked send payload in one datagram or in GELF chunks
+func (g *gelfWriter) writeChunked(payload []byte) error {
+	if len(payload) <= g.ChunkSize {
+		_, err := g.conn.Write(payload)
+		return err
+	}
+	size := g.ChunkSize - gelfChunkHeader
+	count := (len(payload) + size - 1) / size
+	if count > gelfMaxChunks {
+		return fmt.Errorf("logg: gelf message of %d bytes needs more than %d chunks", len(payload), gelfMaxChunks)
+	}
+	chunk := make([]byte, gelfChunkHeader, g.ChunkSize)
+	chunk[0], chunk[1] = 0x1e, 0x0f
+	if _, err := rand.Read(chunk[2:10]); err != nil {
+		return err
+	}
+	chunk[11] = byte(count)
+	for i := 0; i < count; i++ {
+		chunk[10] = byte(i)
+		end := (i + 1) * size
+		if end > len(payload) {
+			end = len(payload)
+		}
+		if _, err := g.conn.Write(append(chunk[:gelfChunkHeader], payload[i*size:end]...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//appendGelf encode e as a GELF 1.1 payload, the first line of the message is
+//short_message, the user fields are "_" prefixed with groups joined by "_"
+func appendGelf(buf *bytes.Buffer, e *Entry, host string) {
+	obj := &fieldObject{}
+	obj.set("version", "1.1")
+	obj.set("host", host)
+	short := e.Msg
+	if i := strings.IndexByte(short, '\n'); i >= 0 {
+		short = short[:i]
+		obj.set("short_message", short)
+		obj.set("full_message", e.Msg)
+	} else {
+		obj.set("short_message", short)
+	}
+	obj.set("timestamp", float64(e.When.UnixNano()/int64(time.Millisecond))/1000)
+	obj.set("level", syslogSeverities[e.Level])
+	if len(e.Caller) > 0 {
+		obj.set("_caller", e.Caller)
+	}
+	setGelfFields(obj, "", userFields(e.Fields, nil))
+	writeJSONObject(buf, obj)
+}
+
+func setGelfFields(obj *fieldObject, prefix string, o *fieldObject) {
+	for _, key := range o.keys {
+		name := prefix + "_" + gelfFieldName.ReplaceAllString(key, "_")
+		if sub, ok := o.values[key].(*fieldObject); ok {
+			setGelfFields(obj, name, sub)
+			continue
+		}
+		if name == "_id" {
+			//reserved by GELF
+			name = "__id"
+		}
+		obj.set(name, gelfValue(o.values[key]))
+	}
+}
+
+//gelfValue numbers as they are, the other values as strings
+func gelfValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case json.Number:
+		return t
+	case error:
+		return t.Error()
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+func (g *gelfWriter) Flush() {
+}
+
+func (g *gelfWriter) Destroy() {
+	g.Lock()
+	defer g.Unlock()
+	if g.conn != nil {
+		g.conn.Close()
+		g.conn = nil
+	}
+}
+
+func init() {
+	RegisterAppender("gelf", newGelfAppender)
+}
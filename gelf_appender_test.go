@@ -0,0 +1,113 @@
+package logg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGelfPayload(t *testing.T) {
+	when := time.Date(2020, 1, 2, 3, 4, 5, 123e6, time.UTC)
+	e := &Entry{When: when, Level: LevelWarn, Msg: "disk\nfull", Caller: "app.go:3",
+		Fields: []Field{{"id", 9}, Group("req", Field{"path", "/a"}), {"ok", true}, {"bad key", 1.5}}}
+	var buf bytes.Buffer
+	appendGelf(&buf, e, "web-1")
+	want := `{"version":"1.1","host":"web-1","short_message":"disk","full_message":"disk\nfull",` +
+		`"timestamp":1577934245.123,"level":4,"_caller":"app.go:3","__id":9,"_req_path":"/a","_ok":"true","_bad_key":1.5}`
+	if buf.String() != want {
+		t.Fatalf("got %s\nwant %s", buf.String(), want)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGelfUDPChunks(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	log := NewLogger(10)
+	if err := log.SetAppender("gelf", `{"address":"`+server.LocalAddr().String()+`","host":"h","chunksize":100}`); err != nil {
+		t.Fatal(err)
+	}
+	msg := strings.Repeat("x", 500)
+	log.Info("%s", msg)
+	log.Close()
+
+	server.SetReadDeadline(time.Now().Add(time.Second))
+	chunks := map[byte][]byte{}
+	count := 0
+	for count == 0 || len(chunks) < count {
+		data := make([]byte, 200)
+		n, _, err := server.ReadFrom(data)
+		if err != nil {
+			t.Fatalf("read chunk: %v", err)
+		}
+		data = data[:n]
+		if n > 100 || data[0] != 0x1e || data[1] != 0x0f {
+			t.Fatalf("bad chunk %q", data)
+		}
+		count = int(data[11])
+		chunks[data[10]] = data[12:]
+	}
+	var payload []byte
+	for i := 0; i < count; i++ {
+		payload = append(payload, chunks[byte(i)]...)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("reassembled payload %q: %v", payload, err)
+	}
+	if decoded["short_message"] != msg || decoded["level"] != float64(6) {
+		t.Fatalf("got %v", decoded)
+	}
+}
+
+func TestGelfTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		var frames []string
+		for len(frames) < 2 {
+			frame, err := r.ReadString(0)
+			if err != nil {
+				break
+			}
+			frames = append(frames, strings.TrimSuffix(frame, "\x00"))
+		}
+		received <- frames
+	}()
+	log := NewLogger(10)
+	if err := log.SetAppender("gelf", `{"address":"`+ln.Addr().String()+`","protocol":"tcp","host":"h"}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Error("first")
+	log.Debug("second")
+	log.Close()
+	select {
+	case frames := <-received:
+		if len(frames) != 2 || !strings.Contains(frames[0], `"short_message":"first","timestamp":`) ||
+			!strings.Contains(frames[1], `"level":7`) {
+			t.Fatalf("got %q", frames)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no frames received")
+	}
+}
@@ -0,0 +1,175 @@
+//go:build linux
+// +build linux
+
+package logg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//journalSocket the socket of journald's native protocol
+const journalSocket = "/run/systemd/journal/socket"
+
+//journaldWriter send messages with their fields to journald. A datagram is
+//limited by the socket buffer, larger messages fail with an error
+type journaldWriter struct {
+	sync.Mutex
+	Level      int    `json:"level"`
+	MaxLevel   int    `json:"maxlevel"`
+	Identifier string `json:"identifier"` //SYSLOG_IDENTIFIER, the program name by default
+	Socket     string `json:"socket"`
+	conn       *net.UnixConn
+	addr       *net.UnixAddr
+	buf        bytes.Buffer
+}
+
+func newJournaldAppender() Appender {
+	return &journaldWriter{
+		Level:      LevelDebug,
+		Identifier: filepath.Base(os.Args[0]),
+		Socket:     journalSocket,
+	}
+}
+
+//Init config like
+//{
+//"level":4,
+//"maxlevel":0,
+//"identifier":"app",
+//}
+func (j *journaldWriter) Init(config string) error {
+	if len(config) > 0 {
+		if err := json.Unmarshal([]byte(config), j); err != nil {
+			return err
+		}
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	j.conn = conn
+	j.addr = &net.UnixAddr{Name: j.Socket, Net: "unixgram"}
+	return nil
+}
+
+func (j *journaldWriter) WriteMsg(when time.Time, msg string, level int) error {
+	return j.WriteEntry(&Entry{When: when, Level: level, Msg: msg})
+}
+
+func (j *journaldWriter) WriteEntry(e *Entry) error {
+	if e.Level > j.Level || e.Level < j.MaxLevel {
+		return nil
+	}
+	j.Lock()
+	defer j.Unlock()
+	if j.conn == nil {
+		return errors.New("logg: journald appender is closed")
+	}
+	j.buf.Reset()
+	appendJournalEntry(&j.buf, e, j.Identifier)
+	_, err := j.conn.WriteToUnix(j.buf.Bytes(), j.addr)
+	return err
+}
+
+//appendJournalEntry serialize e in journald's native format: PRIORITY,
+//MESSAGE, SYSLOG_IDENTIFIER, CODE_FILE and CODE_LINE then the user fields
+//with their keys made valid journal field names, like "req.id" to REQ_ID
+func appendJournalEntry(buf *bytes.Buffer, e *Entry, identifier string) {
+	appendJournalField(buf, "PRIORITY", strconv.Itoa(syslogSeverities[e.Level]))
+	appendJournalField(buf, "MESSAGE", e.Msg)
+	if len(identifier) > 0 {
+		appendJournalField(buf, "SYSLOG_IDENTIFIER", identifier)
+	}
+	if i := strings.LastIndexByte(e.Caller, ':'); i > 0 {
+		appendJournalField(buf, "CODE_FILE", e.Caller[:i])
+		appendJournalField(buf, "CODE_LINE", e.Caller[i+1:])
+	}
+	if len(e.Func) > 0 {
+		appendJournalField(buf, "CODE_FUNC", e.Func)
+	}
+	fields := userFields(e.Fields, nil)
+	appendJournalObject(buf, "", fields)
+}
+
+func appendJournalObject(buf *bytes.Buffer, prefix string, o *fieldObject) {
+	for _, key := range o.keys {
+		name := key
+		if len(prefix) > 0 {
+			name = prefix + "_" + key
+		}
+		if sub, ok := o.values[key].(*fieldObject); ok {
+			appendJournalObject(buf, name, sub)
+			continue
+		}
+		if name = journalFieldName(name); len(name) > 0 {
+			appendJournalField(buf, name, fmt.Sprint(o.values[key]))
+		}
+	}
+}
+
+//journalFieldName upper case letters, digits and underscores, not starting
+//with an underscore (reserved to journald) or a digit, empty when nothing is left
+func journalFieldName(key string) string {
+	b := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			b = append(b, c-'a'+'A')
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			b = append(b, c)
+		default:
+			b = append(b, '_')
+		}
+	}
+	name := strings.TrimLeft(string(b), "_0123456789")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+//appendJournalField write `NAME=value\n`, or for values holding a newline
+//NAME\n, the value length as a little endian uint64, the value and \n
+func appendJournalField(buf *bytes.Buffer, name string, value string) {
+	buf.WriteString(name)
+	if strings.IndexByte(value, '\n') < 0 {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.Write(size[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+func (j *journaldWriter) Flush() {
+}
+
+func (j *journaldWriter) Destroy() {
+	j.Lock()
+	defer j.Unlock()
+	if j.conn != nil {
+		j.conn.Close()
+		j.conn = nil
+	}
+}
+
+func init() {
+	RegisterAppender("journald", newJournaldAppender)
+}
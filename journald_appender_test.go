@@ -0,0 +1,56 @@
+//go:build linux
+// +build linux
+
+package logg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJournalFields(t *testing.T) {
+	e := &Entry{Level: LevelWarn, Msg: "two\nlines", Caller: "app.go:12",
+		Fields: []Field{{"user-id", 7}, Group("req", Field{"path", "/a"}), {"_hidden", 1}, {"9", "dropped"}}}
+	var buf bytes.Buffer
+	appendJournalEntry(&buf, e, "app")
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], 9)
+	want := "PRIORITY=4\nMESSAGE\n" + string(size[:]) + "two\nlines\nSYSLOG_IDENTIFIER=app\n" +
+		"CODE_FILE=app.go\nCODE_LINE=12\nUSER_ID=7\nREQ_PATH=/a\nHIDDEN=1\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestJournaldAppender(t *testing.T) {
+	if _, err := os.Stat(journalSocket); err != nil {
+		t.Skip("no journald socket")
+	}
+	//a fake journald so the test does not write to the real journal
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+	server, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	log := NewLogger(10)
+	if err := log.SetAppender("journald", `{"socket":"`+socket+`","identifier":"logg-test"}`); err != nil {
+		t.Fatal(err)
+	}
+	log.With(Field{"id", 1}).Error("failed")
+	log.Close()
+	server.SetReadDeadline(time.Now().Add(time.Second))
+	data := make([]byte, 4096)
+	n, err := server.Read(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "PRIORITY=3\nMESSAGE=failed\nSYSLOG_IDENTIFIER=logg-test\nID=1\n"; string(data[:n]) != want {
+		t.Fatalf("got %q, want %q", data[:n], want)
+	}
+}
@@ -0,0 +1,139 @@
+package logg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//JSONFormatter render one JSON object per entry, groups become nested objects.
+//Keys repeated in the same object keep their first position and the last value,
+//except two groups which are merged. Fields named like a builtin key are
+//rendered as "fields.<key>". An empty message has no "msg" key, see Event.
+type JSONFormatter struct {
+	fieldFormatter
+}
+
+//SetFieldKeys rename the time, level and msg keys, like ("@timestamp", "",
+//"message"), an empty name keeps the default one. The "timekey", "levelkey"
+//and "msgkey" appender options call it
+func (j *JSONFormatter) SetFieldKeys(time, level, msg string) error {
+	return j.setFieldKeys(time, level, msg)
+}
+
+//Format Formatter interface
+func (j *JSONFormatter) Format(e *Entry) []byte {
+	keys := j.format.orDefault()
+	obj := &fieldObject{}
+	obj.set(keys.time, e.When.Format(time.RFC3339Nano))
+	obj.set(keys.level, levelNames[e.Level])
+	if len(e.Msg) > 0 {
+		obj.set(keys.msg, e.Msg)
+	}
+	if len(e.Caller) > 0 {
+		obj.set("caller", e.Caller)
+	}
+	if len(e.Func) > 0 {
+		obj.set("func", e.Func)
+	}
+	if len(e.Version) > 0 {
+		obj.set("version", e.Version)
+	}
+	if e.Seq > 0 {
+		obj.set("seq", e.Seq)
+	}
+	fields := userFields(e.Fields, keys)
+	for _, key := range fields.keys {
+		obj.set(key, fields.values[key])
+	}
+	var buf bytes.Buffer
+	writeJSONObject(&buf, obj)
+	return buf.Bytes()
+}
+
+func writeJSONObject(buf *bytes.Buffer, o *fieldObject) {
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeJSONValue(buf, key)
+		buf.WriteByte(':')
+		if sub, ok := o.values[key].(*fieldObject); ok {
+			writeJSONObject(buf, sub)
+		} else {
+			writeJSONValue(buf, o.values[key])
+		}
+	}
+	buf.WriteByte('}')
+}
+
+//writeJSONValue keep the native JSON type of v: numbers and booleans are not
+//quoted, nil is null, slices and maps are arrays and objects
+func writeJSONValue(buf *bytes.Buffer, v interface{}) {
+	switch t := v.(type) {
+	case nil:
+		buf.WriteString("null")
+		return
+	case bool:
+		buf.WriteString(strconv.FormatBool(t))
+		return
+	case int:
+		buf.WriteString(strconv.Itoa(t))
+		return
+	case int64:
+		buf.WriteString(strconv.FormatInt(t, 10))
+		return
+	case uint64:
+		buf.WriteString(strconv.FormatUint(t, 10))
+		return
+	case error:
+		v = t.Error()
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONValue(buf, elem)
+		}
+		buf.WriteByte(']')
+		return
+	case map[string]interface{}:
+		if t == nil {
+			buf.WriteString("null")
+			return
+		}
+		keys := make([]string, 0, len(t))
+		for key := range t {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONValue(buf, key)
+			buf.WriteByte(':')
+			writeJSONValue(buf, t[key])
+		}
+		buf.WriteByte('}')
+		return
+	}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		out.Reset()
+		enc.Encode(fmt.Sprintf("%v", v))
+	}
+	buf.Write(bytes.TrimSuffix(out.Bytes(), []byte("\n")))
+}
+
+func init() {
+	RegisterFormatter("json", func() Formatter { return &JSONFormatter{} })
+}
@@ -0,0 +1,57 @@
+package logg
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type levelPayload struct {
+	Level string `json:"level,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+//LevelHandler serve the level of log as {"level":"info"}, GET reads it and
+//PUT or POST set it from a {"level":"debug"} JSON body or a "level" form
+//value, answering the new level
+func (log *BaseLogger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var req levelPayload
+			if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					writeLevelPayload(w, http.StatusBadRequest, levelPayload{Error: err.Error()})
+					return
+				}
+			} else {
+				req.Level = r.FormValue("level")
+			}
+			if err := log.SetLevelString(req.Level); err != nil {
+				writeLevelPayload(w, http.StatusBadRequest, levelPayload{Error: err.Error()})
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			writeLevelPayload(w, http.StatusMethodNotAllowed, levelPayload{Error: "logg: method " + r.Method + " not allowed"})
+			return
+		}
+		writeLevelPayload(w, http.StatusOK, levelPayload{Level: levelName(log.Level())})
+	})
+}
+
+//levelName the name of level, its number when SetLevel was given another int
+func levelName(level int) string {
+	if level < LevelFatal || level > LevelDebug {
+		return strconv.Itoa(level)
+	}
+	return levelNames[level]
+}
+
+func writeLevelPayload(w http.ResponseWriter, status int, p levelPayload) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(p)
+}
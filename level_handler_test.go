@@ -0,0 +1,77 @@
+package logg
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLevelHandler(t *testing.T) {
+	log := NewLogger(10)
+	defer log.Close()
+	log.SetLevel(LevelInfo)
+	server := httptest.NewServer(log.LevelHandler())
+	defer server.Close()
+
+	do := func(req *http.Request, status int, body string) {
+		t.Helper()
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		got, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != status || strings.TrimSpace(string(got)) != body {
+			t.Fatalf("%s got %d %s, want %d %s", req.Method, resp.StatusCode, got, status, body)
+		}
+	}
+	get, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	do(get, http.StatusOK, `{"level":"info"}`)
+
+	put, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader(`{"level":"DEBUG"}`))
+	put.Header.Set("Content-Type", "application/json")
+	do(put, http.StatusOK, `{"level":"debug"}`)
+	if log.Level() != LevelDebug {
+		t.Fatalf("level is %d", log.Level())
+	}
+
+	post, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(url.Values{"level": {"warn"}}.Encode()))
+	post.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	do(post, http.StatusOK, `{"level":"warn"}`)
+
+	bad, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader(`{"level":"loud"}`))
+	bad.Header.Set("Content-Type", "application/json")
+	do(bad, http.StatusBadRequest, `{"error":"logg: unknow level loud"}`)
+	del, _ := http.NewRequest(http.MethodDelete, server.URL, nil)
+	do(del, http.StatusMethodNotAllowed, `{"error":"logg: method DELETE not allowed"}`)
+	if log.Level() != LevelWarn {
+		t.Fatalf("level is %d after failed requests", log.Level())
+	}
+
+	log.SetLevel(LevelDebug + 1)
+	do(get, http.StatusOK, `{"level":"5"}`)
+}
+
+func TestLevelHandlerConcurrent(t *testing.T) {
+	log := NewLogger(10)
+	defer log.Close()
+	log.AddAppender("count", &countAppender{})
+	handler := log.LevelHandler()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			log.Debug("message %d", i)
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		level := []string{"debug", "info"}[i%2]
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("level="+level))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	<-done
+}
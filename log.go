@@ -1,14 +1,19 @@
 package logg
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
-	"path"
+	"os/signal"
 	"runtime"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/colefan/config"
 )
@@ -32,6 +37,55 @@ type Appender interface {
 	Flush()
 }
 
+//EntryAppender is implemented by appenders that want the structured Entry
+//instead of the rendered text, e must not be retained after WriteEntry returns
+type EntryAppender interface {
+	WriteEntry(e *Entry) error
+}
+
+//Rotatable is implemented by appenders that can rotate their output on demand
+type Rotatable interface {
+	Rotate() error
+}
+
+//FileAppenderControl is implemented by the file appender to change its
+//rotation settings while it is running, see BaseLogger.FileControl
+type FileAppenderControl interface {
+	SetMaxSize(maxSize int)
+	SetMaxDays(maxDays int)
+	SetDaily(daily bool)
+}
+
+//Reopenable is implemented by appenders that can reopen their output,
+//e.g. after an external rotation
+type Reopenable interface {
+	Reopen() error
+}
+
+//RotateNotifier is implemented by appenders that can report their rotated
+//files, like the file appender
+type RotateNotifier interface {
+	OnRotate(fn func(path string))
+}
+
+//HealthChecker is implemented by appenders that can probe their output,
+//like the file appender checking its file can still be written
+type HealthChecker interface {
+	HealthCheck() error
+}
+
+//ErrorNotifier is implemented by appenders that can report their errors
+//as they happen, like the file appender
+type ErrorNotifier interface {
+	OnError(fn func(err error))
+}
+
+//DeleteNotifier is implemented by appenders that can report the old files
+//they delete, like the file appender
+type DeleteNotifier interface {
+	OnDelete(fn func(path string))
+}
+
 type createAppender func() Appender
 
 var appenderMap = make(map[string]createAppender)
@@ -49,27 +103,99 @@ func RegisterAppender(name string, appender createAppender) {
 
 type nameAppender struct {
 	Appender
-	name string
+	name   string
+	config string
+	loaded bool //created by LoadConfig, managed by ReloadConfig
+	pinned bool //not removable, like the audit appender
+	//fallback written only when the appender fails, see SetFallback
+	fallback Appender
+}
+
+//Flush flush the appender then its fallback
+func (n *nameAppender) Flush() {
+	n.Appender.Flush()
+	if n.fallback != nil {
+		n.fallback.Flush()
+	}
+}
+
+//fallbacksOf the fallbacks still in use by appenders, must hold the lock
+func fallbacksOf(appenders []*nameAppender) map[Appender]bool {
+	inUse := make(map[Appender]bool)
+	for _, out := range appenders {
+		if out.fallback != nil {
+			inUse[out.fallback] = true
+		}
+	}
+	return inUse
 }
 
-type logMsg struct {
-	level int
-	msg   string
-	when  time.Time
+//destroyNamed destroy removed then their fallbacks, a fallback shared by
+//several of them is destroyed once and one in inUse is kept
+func destroyNamed(removed []*nameAppender, inUse map[Appender]bool) {
+	for _, out := range removed {
+		out.Appender.Destroy()
+		if out.fallback != nil && !inUse[out.fallback] {
+			inUse[out.fallback] = true
+			out.fallback.Destroy()
+		}
+	}
 }
 
 //BaseLogger struct of logger
 type BaseLogger struct {
-	lock                sync.Mutex
-	level               int
+	//64-bit atomics first, aligned on 386 and arm
+	levelFiltered [LevelDebug + 1]uint64 //per level counts of Stats
+
+	lock                sync.RWMutex
+	level               int32 //atomic, SetLevelFor's timer changes it
+	levelLock           sync.Mutex
+	levelTimer          *time.Timer
+	levelBase           int
 	enableFuncCallDepth bool
+	callerFunc          bool
+	callerFormat        CallerFormat
 	loggerFuncCallDepth int
-	msgChan             chan *logMsg
+	msgChan             chan *Entry
 	appenders           []*nameAppender
 	async               bool
+	asyncOnce           sync.Once
 	logMsgPool          *sync.Pool
-	singalChan          chan string
 	wg                  sync.WaitGroup
+	workers             int
+	release             chan struct{} //resume the workers held by Flush
+	signalLock          sync.Mutex    //one Flush or Close signals the workers at a time
+	closed              bool          //set by Close under signalLock
+	closeOnce           sync.Once
+	done                chan struct{} //closed by Close
+	paused              int32
+	pauseLock           sync.Mutex
+	pauseBuffer         int
+	pausedMsgs          []*Entry
+	deferred            bool //Defer keeps every message, not pauseBuffer
+	resuming            bool //Resume is writing pausedMsgs, new ones are all kept
+	extractors          []ContextExtractor
+	errorHandler        func(appenderName string, err error)
+	goroutineIDs        int32 //set once an appender wants Entry.Goroutine
+	maxMsgLen           int32
+	fatalExit           int32 //EnableFatalExit
+	fatalExitCode       int32
+	utc                 int32
+	sequence            int32        //SetSequence
+	flushEveryN         int32        //SetFlushEveryN
+	unflushed           int32        //messages written since the last flush
+	backpressure        atomic.Value //*backpressure
+	sampler             atomic.Value //*sampler
+	levelSampler        atomic.Value //*levelSampler
+	samplingLock        sync.Mutex   //SetLevelSampling, Flush and Close replace or flush it
+	includeFilter       atomic.Value //*regexp.Regexp
+	excludeFilter       atomic.Value //*regexp.Regexp
+	versionTag          atomic.Value //string
+	rotateHook          atomic.Value //func(path string), set by OnRotate
+	spill               *spill       //set by SetSpillFile
+	flags               *logFlags    //set by RegisterFlags
+	seqLock             sync.Mutex   //numbers and queues the messages of SetSequence
+	seq                 uint64       //the last number of SetSequence
 }
 
 //NewLogger create a logger
@@ -78,119 +204,706 @@ func NewLogger(channelLen int) *BaseLogger {
 	log.level = LevelDebug
 	log.loggerFuncCallDepth = 2
 	log.enableFuncCallDepth = false
-	log.msgChan = make(chan *logMsg, channelLen)
-	log.singalChan = make(chan string, 1)
+	log.msgChan = make(chan *Entry, channelLen)
+	log.release = make(chan struct{})
+	log.done = make(chan struct{})
+	log.workers = 1
+	log.fatalExitCode = 1
 	log.async = false
+	log.logMsgPool = &sync.Pool{
+		New: func() interface{} {
+			return &Entry{}
+		},
+	}
 	return log
 }
 
 //SetAppender
 func (log *BaseLogger) SetAppender(appenderName string, config string) error {
+	return log.setAppender(appenderName, config, false)
+}
+
+//AppenderSpec one appender of SetAppenders, Name as registered and its config
+type AppenderSpec struct {
+	Name   string
+	Config string
+}
+
+//SetAppenders SetAppender every spec in order, the ones failing are skipped
+//and listed by the returned *LoadConfigError
+func (log *BaseLogger) SetAppenders(specs []AppenderSpec) error {
+	var errs []error
+	for _, spec := range specs {
+		if err := log.SetAppender(spec.Name, spec.Config); err != nil {
+			errs = append(errs, errors.New(spec.Name+": "+err.Error()))
+		}
+	}
+	if len(errs) > 0 {
+		return &LoadConfigError{Errs: errs, op: "SetAppenders"}
+	}
+	return nil
+}
+
+//setAppender create and init the appender without the lock, an init
+//retrying like the file "openattempts" doesn't stall the writers
+func (log *BaseLogger) setAppender(appenderName string, config string, loaded bool) error {
+	log.lock.RLock()
+	err := checkDuplicate(log.appenders, appenderName)
+	log.lock.RUnlock()
+	if err != nil {
+		return err
+	}
+	out, err := newAppender(appenderName, config)
+	if err != nil {
+		return err
+	}
 	log.lock.Lock()
-	defer log.lock.Unlock()
+	if err := checkDuplicate(log.appenders, appenderName); err != nil {
+		log.lock.Unlock()
+		out.Destroy()
+		return err
+	}
+	log.attach(out)
+	log.appenders = append(log.appenders, &nameAppender{name: appenderName, Appender: out, config: config, loaded: loaded})
+	log.lock.Unlock()
+	return nil
+}
+
+//attach prepare out for the logger: writeMsg records goroutine ids if out
+//needs them and the OnRotate hook is set
+func (log *BaseLogger) attach(out Appender) {
+	if wantsGoroutine(out) {
+		atomic.StoreInt32(&log.goroutineIDs, 1)
+	}
+	if fn, _ := log.rotateHook.Load().(func(path string)); fn != nil {
+		if rn, ok := out.(RotateNotifier); ok {
+			rn.OnRotate(fn)
+		}
+	}
+}
+
+//checkDuplicate only one console appender is allowed
+func checkDuplicate(appenders []*nameAppender, appenderName string) error {
 	if appenderName == "console" {
-		for _, appender := range log.appenders {
+		for _, appender := range appenders {
 			if appender.name == appenderName {
 				return errors.New("logg:duplicate appenderName " + appenderName + " (you have set this appender before)")
 			}
 		}
 	}
+	return nil
+}
+
+func newAppender(appenderName string, config string) (Appender, error) {
 	appender, ok := appenderMap[appenderName]
 	if !ok {
-		return errors.New("logg:unknow appenderName " + appenderName + " (forgotten RegisterAppender?)")
+		return nil, errors.New("logg:unknow appenderName " + appenderName + " (forgotten RegisterAppender?)")
 	}
 	out := appender()
 	err := out.Init(config)
 	if err != nil {
-		return errors.New("logg: appender init error " + err.Error())
+		return nil, errors.New("logg: appender init error " + err.Error())
+	}
+	return out, nil
+}
+
+//AddAppender attach out, already initialized by the caller, under name
+func (log *BaseLogger) AddAppender(name string, out Appender) {
+	log.lock.Lock()
+	log.attach(out)
+	log.appenders = append(log.appenders, &nameAppender{name: name, Appender: out})
+	log.lock.Unlock()
+}
+
+//RemoveAppender flush, destroy and detach the appenders named name,
+//the audit appender can not be removed
+func (log *BaseLogger) RemoveAppender(name string) error {
+	log.lock.Lock()
+	var appenders, removed []*nameAppender
+	pinned := false
+	for _, out := range log.appenders {
+		if out.name == name && !out.pinned {
+			removed = append(removed, out)
+		} else {
+			pinned = pinned || (out.name == name)
+			appenders = append(appenders, out)
+		}
+	}
+	log.appenders = appenders
+	inUse := fallbacksOf(appenders)
+	log.lock.Unlock()
+	for _, out := range removed {
+		out.Flush()
+	}
+	destroyNamed(removed, inUse)
+	if len(removed) == 0 {
+		if pinned {
+			return errors.New("logg: appender " + name + " can not be removed")
+		}
+		return errors.New("logg: no appender named " + name)
 	}
-	log.appenders = append(log.appenders, &nameAppender{name: appenderName, Appender: out})
 	return nil
 }
 
-//Async asynchroonous and start the goroutine
-func (log *BaseLogger) Async() *BaseLogger {
-	log.async = true
-	log.logMsgPool = &sync.Pool{
-		New: func() interface{} {
-			return &logMsg{}
-		},
+//Reset flush then destroy and detach the appenders, the audit appender
+//excepted, and restore the default level and caller settings. The async
+//workers keep running, the logger is configured again like a new one
+func (log *BaseLogger) Reset() {
+	log.Flush()
+	log.lock.Lock()
+	var kept, removed []*nameAppender
+	for _, out := range log.appenders {
+		if out.pinned {
+			kept = append(kept, out)
+		} else {
+			removed = append(removed, out)
+		}
+	}
+	destroyNamed(removed, fallbacksOf(kept))
+	log.appenders = kept
+	log.lock.Unlock()
+	log.SetLevel(LevelDebug)
+	log.loggerFuncCallDepth = 2
+	log.enableFuncCallDepth = false
+	log.callerFunc = false
+	log.callerFormat = CallerShort
+}
+
+//FileControl return the FileAppenderControl of the first appender named name
+func (log *BaseLogger) FileControl(name string) (FileAppenderControl, error) {
+	log.lock.RLock()
+	defer log.lock.RUnlock()
+	for _, out := range log.appenders {
+		if out.name != name {
+			continue
+		}
+		if c, ok := out.Appender.(FileAppenderControl); ok {
+			return c, nil
+		}
+		return nil, errors.New("logg: appender " + name + " has no file control")
+	}
+	return nil, errors.New("logg: no appender named " + name)
+}
+
+//FlushAppender flush only the appenders named name, messages still queued by
+//an async logger are not drained
+func (log *BaseLogger) FlushAppender(name string) error {
+	log.lock.RLock()
+	defer log.lock.RUnlock()
+	found := false
+	for _, out := range log.appenders {
+		if out.name == name {
+			out.Flush()
+			found = true
+		}
 	}
-	log.wg.Add(1)
-	go log.startLogging()
+	if !found {
+		return errors.New("logg: no appender named " + name)
+	}
+	return nil
+}
+
+//EnableAudit attach an append-only "audit" file appender receiving every Warn
+//and more severe message, synced on each write and never rotated. It is not
+//affected by RemoveAppender or ReloadConfig
+func (log *BaseLogger) EnableAudit(filename string) error {
+	config, err := json.Marshal(map[string]interface{}{
+		"filename":       filename,
+		"level":          LevelWarn,
+		"rotate":         false,
+		"synceverywrite": true,
+	})
+	if err != nil {
+		return err
+	}
+	out, err := newAppender("file", string(config))
+	if err != nil {
+		return err
+	}
+	log.lock.Lock()
+	log.appenders = append(log.appenders, &nameAppender{name: "audit", Appender: out, pinned: true})
+	log.lock.Unlock()
+	return nil
+}
+
+//Async asynchroonous and start the goroutine, a single worker writes the
+//messages in the order they were queued. Later calls do nothing
+func (log *BaseLogger) Async() *BaseLogger {
+	log.asyncOnce.Do(func() {
+		log.async = true
+		for i := 0; i < log.workers; i++ {
+			go log.startLogging()
+		}
+		if log.spill != nil {
+			go log.spill.replay()
+		}
+	})
 	return log
 }
 
-func (log *BaseLogger) startLogging() {
-	gameOver := false
-	for {
+//IsAsync report whether Async was called
+func (log *BaseLogger) IsAsync() bool {
+	return log.async
+}
+
+//PendingCount the number of messages an async logger queued and no worker
+//took yet, the spilled ones included
+func (log *BaseLogger) PendingCount() int {
+	n := len(log.msgChan)
+	if s := log.spill; s != nil {
+		s.lock.Lock()
+		n += s.pending
+		s.lock.Unlock()
+	}
+	return n
+}
+
+//DrainPending remove the messages queued in the channel of an async logger
+//and return their text, to see what a stuck logger holds. A channel can not
+//be read without consuming it, the drained messages are never written
+func (log *BaseLogger) DrainPending() []string {
+	var texts []string
+	for n := len(log.msgChan); n > 0; n-- {
+		var e *Entry
 		select {
-		case msg := <-log.msgChan:
-			log.writeToAppender(msg.when, msg.msg, msg.level)
-			log.logMsgPool.Put(msg)
-		case sg := <-log.singalChan:
-			log.flush()
-			if sg == "close" {
-				for _, out := range log.appenders {
-					out.Destroy()
-				}
-				log.appenders = nil
-				gameOver = true
-			}
-			log.wg.Done()
+		case e = <-log.msgChan:
+		default:
+			return texts
+		}
+		if e == flushMarker || e == closeMarker {
+			//a Flush or Close waits for a worker to take it
+			go func() { log.msgChan <- e }()
+			continue
+		}
+		texts = append(texts, e.Stamp()+e.Text())
+		log.logMsgPool.Put(e)
+	}
+	return texts
+}
+
+//SetWorkers set the number of goroutines Async starts to write the messages,
+//1 by default. With more than one a slow appender does not hold the others
+//back, but messages may reach the appenders out of order. Call it before Async,
+//it is ignored after: Flush and Close wait for one marker per started worker
+func (log *BaseLogger) SetWorkers(n int) {
+	if log.async {
+		return
+	}
+	if n < 1 {
+		n = 1
+	}
+	log.workers = n
+}
 
+//flushMarker and closeMarker are queued in msgChan behind the messages a
+//Flush or Close must write first
+var (
+	flushMarker = &Entry{}
+	closeMarker = &Entry{}
+)
+
+func (log *BaseLogger) startLogging() {
+	for msg := range log.msgChan {
+		switch msg {
+		case flushMarker:
+			log.wg.Done()
+			<-log.release
+		case closeMarker:
+			log.wg.Done()
+			return
+		default:
+			log.writeToAppender(msg)
+			log.logMsgPool.Put(msg)
 		}
+	}
+}
 
-		if gameOver {
-			break
+//stopWorkers queue one marker per worker and wait until each of them took
+//one: every message queued before is then written, must hold signalLock
+func (log *BaseLogger) stopWorkers(marker *Entry) {
+	log.wg.Add(log.workers)
+	for i := 0; i < log.workers; i++ {
+		log.msgChan <- marker
+	}
+	log.wg.Wait()
+}
+
+//writeToAppender write e to every appender in slice order. The read lock is
+//held for the whole message so an appender added or removed meanwhile gets
+//all of it or nothing: with a single worker every appender sees the messages
+//in the same order
+func (log *BaseLogger) writeToAppender(e *Entry) {
+	log.lock.RLock()
+	defer log.lock.RUnlock()
+	for _, out := range log.appenders {
+		err := writeEntry(out.Appender, e)
+		if err == nil {
+			continue
+		}
+		log.reportWriteError(out.name, err)
+		if out.fallback != nil {
+			if err := writeEntry(out.fallback, e); err != nil {
+				log.reportWriteError(out.name+" fallback", err)
+			}
 		}
+	}
+	if n := atomic.LoadInt32(&log.flushEveryN); n > 0 {
+		if c := atomic.AddInt32(&log.unflushed, 1); c >= n && atomic.CompareAndSwapInt32(&log.unflushed, c, 0) {
+			for _, out := range log.appenders {
+				out.Flush()
+			}
+		}
+	}
+}
 
+//SetFlushEveryN flush the appenders once n messages were written since the
+//last flush, bounding what a crash loses by count, 0 disables it
+func (log *BaseLogger) SetFlushEveryN(n int) {
+	if n < 0 {
+		n = 0
 	}
+	atomic.StoreInt32(&log.unflushed, 0)
+	atomic.StoreInt32(&log.flushEveryN, int32(n))
+}
 
+func writeEntry(out Appender, e *Entry) error {
+	if ea, ok := out.(EntryAppender); ok {
+		return ea.WriteEntry(e)
+	}
+	return out.WriteMsg(e.When, e.Text(), e.Level)
+}
+
+func (log *BaseLogger) reportWriteError(name string, err error) {
+	if log.errorHandler != nil {
+		log.errorHandler(name, err)
+	} else {
+		fmt.Fprintf(os.Stderr, "unable to WriteMsg to appender:%v,error:%v\n", name, err)
+	}
 }
 
-func (log *BaseLogger) writeToAppender(time time.Time, msg string, level int) {
+//SetFallback write the messages the appenders named name fail to write to
+//fallback, like a console on stderr for a file on a full disk. fallback must
+//already be initialized, it is flushed with them and destroyed once with the
+//last of them
+func (log *BaseLogger) SetFallback(name string, fallback Appender) error {
+	log.attach(fallback)
+	log.lock.Lock()
+	defer log.lock.Unlock()
+	found := false
 	for _, out := range log.appenders {
-		err := out.WriteMsg(time, msg, level)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "unable to WriteMsg to appender:%v,error:%v\n", out.name, err)
+		if out.name == name {
+			out.fallback = fallback
+			found = true
 		}
 	}
+	if !found {
+		return errors.New("logg: no appender named " + name)
+	}
+	return nil
+}
+
+//SetErrorHandler call fn instead of printing to stderr when an appender fails
+//to write a message, a func doing nothing silences them and nil restores
+//stderr. fn runs on the logging path and must not log to this logger
+func (log *BaseLogger) SetErrorHandler(fn func(appenderName string, err error)) {
+	log.lock.Lock()
+	log.errorHandler = fn
+	log.lock.Unlock()
+}
+
+func (log *BaseLogger) writeMsg(level int, msg string, fields []Field) {
+	log.output(1, time.Now(), level, msg, fields)
+	if level == LevelFatal && atomic.LoadInt32(&log.fatalExit) == 1 {
+		log.exitFatal()
+	}
 }
 
-func (log *BaseLogger) writeMsg(level int, msg string) {
-	when := time.Now()
+//output log msg at when, skip is the number of frames between the level
+//method and output
+func (log *BaseLogger) output(skip int, when time.Time, level int, msg string, fields []Field) {
+	if log.filtered(msg) {
+		return
+	}
+	if s, _ := log.sampler.Load().(*sampler); s != nil && !s.allow(level, msg, when) {
+		return
+	}
+	summary := ""
+	if s, _ := log.levelSampler.Load().(*levelSampler); s != nil {
+		if !s.allow(level) {
+			return
+		}
+		summary = s.summary(level, when, false)
+	}
+	caller, function := "", ""
 	if log.enableFuncCallDepth {
-		_, file, line, ok := runtime.Caller(log.loggerFuncCallDepth)
+		pc, file, line, ok := runtime.Caller(log.loggerFuncCallDepth + skip)
 		if !ok {
 			file = "???"
 			line = 0
 		}
-		_, filename := path.Split(file)
-		msg = msg[0:3] + "[" + filename + ":" + strconv.FormatInt(int64(line), 10) + "]" + msg[3:]
+		caller = callerFile(log.callerFormat, pc, file) + ":" + strconv.FormatInt(int64(line), 10)
+		if log.callerFunc && ok {
+			function = funcName(pc)
+		}
+	}
 
+	if atomic.LoadInt32(&log.utc) == 1 {
+		when = when.UTC()
+	}
+	if n := int(atomic.LoadInt32(&log.maxMsgLen)); n > 0 && len(msg) > n {
+		msg = truncate(msg, n)
 	}
+	e := log.logMsgPool.Get().(*Entry)
+	*e = Entry{When: when, Level: level, Msg: msg, Caller: caller, Func: function, Fields: fields, origin: log}
+	e.Version, _ = log.versionTag.Load().(string)
+	if atomic.LoadInt32(&log.goroutineIDs) == 1 {
+		e.Goroutine = goroutineID()
+	}
+	if len(summary) > 0 {
+		s := log.logMsgPool.Get().(*Entry)
+		*s = Entry{When: when, Level: level, Msg: summary, origin: log}
+		log.sendNumbered(s)
+	}
+	log.sendNumbered(e)
+}
 
-	if log.async {
-		m := log.logMsgPool.Get().(*logMsg)
-		m.level = level
-		m.msg = msg
-		m.when = when
-		log.msgChan <- m
+func (log *BaseLogger) send(e *Entry) {
+	if atomic.LoadInt32(&log.paused) == 1 && log.hold(e) {
+		return
+	}
+	log.dispatch(e)
+}
 
+func (log *BaseLogger) dispatch(e *Entry) {
+	if log.async {
+		if bp, _ := log.backpressure.Load().(*backpressure); bp != nil {
+			bp.check(len(log.msgChan), cap(log.msgChan), e.When)
+		}
+		if log.spill != nil && e.via == nil {
+			log.spill.push(e)
+			return
+		}
+		log.msgChan <- e
 	} else {
-		log.writeToAppender(when, msg, level)
+		log.writeToAppender(e)
+		log.logMsgPool.Put(e)
+	}
+}
+
+//backpressureInterval the minimum time between two backpressure callbacks
+const backpressureInterval = time.Second
+
+type backpressure struct {
+	last      int64 //UnixNano of the last callback, first for 32-bit atomics
+	threshold float64
+	cb        func(load int, cap int)
+}
+
+//SetBackpressureCallback call cb with the async channel length and capacity
+//when it is filled to threshold (0.8 for 80%) or more, at most once per
+//second and in its own goroutine so logging is not slowed down. A nil cb
+//removes it
+func (log *BaseLogger) SetBackpressureCallback(threshold float64, cb func(load int, cap int)) {
+	if cb == nil {
+		log.backpressure.Store((*backpressure)(nil))
+		return
+	}
+	log.backpressure.Store(&backpressure{threshold: threshold, cb: cb})
+}
+
+func (bp *backpressure) check(load, capacity int, now time.Time) {
+	if capacity == 0 || float64(load)/float64(capacity) < bp.threshold {
+		return
+	}
+	last := atomic.LoadInt64(&bp.last)
+	if last != 0 && now.UnixNano()-last < int64(backpressureInterval) {
+		return
+	}
+	if atomic.CompareAndSwapInt64(&bp.last, last, now.UnixNano()) {
+		go bp.cb(load, capacity)
+	}
+}
+
+//hold keep or drop e while paused, false if the logger was resumed meanwhile
+func (log *BaseLogger) hold(e *Entry) bool {
+	log.pauseLock.Lock()
+	defer log.pauseLock.Unlock()
+	if atomic.LoadInt32(&log.paused) == 0 {
+		return false
+	}
+	if log.deferred || log.resuming || len(log.pausedMsgs) < log.pauseBuffer {
+		log.pausedMsgs = append(log.pausedMsgs, e)
+	}
+	return true
+}
+
+//SetPauseBuffer set how many messages are kept while paused and written on
+//Resume, messages beyond it are dropped. The default 0 drops all of them
+func (log *BaseLogger) SetPauseBuffer(n int) {
+	log.pauseLock.Lock()
+	log.pauseBuffer = n
+	log.pauseLock.Unlock()
+}
+
+//Pause stop writing to appenders until Resume, see SetPauseBuffer
+func (log *BaseLogger) Pause() {
+	log.pauseLock.Lock()
+	log.resuming = false
+	atomic.StoreInt32(&log.paused, 1)
+	log.pauseLock.Unlock()
+}
+
+//Resume write the messages kept while paused then log normally again. The
+//messages logged meanwhile are kept and written after them, a Pause stops the
+//replay and a Resume during it returns at once
+func (log *BaseLogger) Resume() {
+	log.pauseLock.Lock()
+	if log.resuming {
+		log.pauseLock.Unlock()
+		return
+	}
+	log.resuming = true
+	for log.resuming {
+		msgs := log.pausedMsgs
+		if len(msgs) == 0 {
+			log.resuming = false
+			log.deferred = false
+			atomic.StoreInt32(&log.paused, 0)
+			break
+		}
+		log.pausedMsgs = nil
+		log.pauseLock.Unlock()
+		for _, e := range msgs {
+			log.dispatch(e)
+		}
+		log.pauseLock.Lock()
 	}
+	log.pauseLock.Unlock()
 }
 
-//SetLevel setter
+//Defer hold every message until Start, create the logger, call Defer, Async
+//and attach the appenders: the messages logged at startup meanwhile are all
+//written to every appender by Start, or by Close if it comes first like on a
+//fatal exit
+func (log *BaseLogger) Defer() {
+	log.pauseLock.Lock()
+	log.deferred = true
+	atomic.StoreInt32(&log.paused, 1)
+	log.pauseLock.Unlock()
+}
+
+//Start write the messages held since Defer in order then log normally
+func (log *BaseLogger) Start() {
+	log.Resume()
+}
+
+//truncatedMarker end of the messages cut by SetMaxMessageLen
+const truncatedMarker = "…(truncated)"
+
+//SetMaxMessageLen cut messages longer than n bytes and mark them with
+//"…(truncated)", 0 means unlimited
+func (log *BaseLogger) SetMaxMessageLen(n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt32(&log.maxMsgLen, int32(n))
+}
+
+//truncate cut msg to n bytes without splitting a UTF-8 rune
+func truncate(msg string, n int) string {
+	for n > 0 && !utf8.RuneStart(msg[n]) {
+		n--
+	}
+	return msg[:n] + truncatedMarker
+}
+
+//sprintf fmt.Sprintf, without allocating when there is nothing to format
+func sprintf(format string, v ...interface{}) string {
+	if len(v) == 0 && strings.IndexByte(format, '%') < 0 {
+		return format
+	}
+	return fmt.Sprintf(format, v...)
+}
+
+//SetLevel setter, it cancels a pending SetLevelFor restoration
 func (log *BaseLogger) SetLevel(level int) {
-	log.level = level
+	log.levelLock.Lock()
+	if log.levelTimer != nil {
+		log.levelTimer.Stop()
+		log.levelTimer = nil
+	}
+	atomic.StoreInt32(&log.level, int32(level))
+	log.levelLock.Unlock()
+}
+
+//SetLevelFor set level for d then restore the level set before, like Debug
+//during an incident. A later SetLevelFor replaces the pending one and still
+//restores the level in use before the first of them, a SetLevel cancels it
+func (log *BaseLogger) SetLevelFor(level int, d time.Duration) {
+	log.levelLock.Lock()
+	defer log.levelLock.Unlock()
+	if log.levelTimer != nil {
+		log.levelTimer.Stop()
+	} else {
+		log.levelBase = log.Level()
+	}
+	atomic.StoreInt32(&log.level, int32(level))
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		log.levelLock.Lock()
+		if log.levelTimer == timer {
+			log.levelTimer = nil
+			atomic.StoreInt32(&log.level, int32(log.levelBase))
+		}
+		log.levelLock.Unlock()
+	})
+	log.levelTimer = timer
 }
 
 //Level getter
 func (log *BaseLogger) Level() int {
-	return log.level
+	return int(atomic.LoadInt32(&log.level))
+}
+
+//skipped report whether level is above the logger level, counting it in
+//Stats when it is
+func (log *BaseLogger) skipped(level int) bool {
+	return log.skippedAt(level, log.Level())
+}
+
+//skippedAt skipped with the threshold level of e.g. WithLevel
+func (log *BaseLogger) skippedAt(level, threshold int) bool {
+	if level <= threshold {
+		return false
+	}
+	atomic.AddUint64(&log.levelFiltered[level], 1)
+	return true
+}
+
+//Stats counters of a logger
+type Stats struct {
+	//Filtered the messages of each level, indexed by level, discarded by the
+	//level methods because the logger level was lower
+	Filtered [LevelDebug + 1]uint64
+}
+
+//Stats return the counters of the logger since it was created
+func (log *BaseLogger) Stats() Stats {
+	var s Stats
+	for level := range s.Filtered {
+		s.Filtered[level] = atomic.LoadUint64(&log.levelFiltered[level])
+	}
+	return s
+}
+
+//SetLevelString SetLevel with a level name like "debug", case insensitive
+func (log *BaseLogger) SetLevelString(level string) error {
+	v, ok := levelStrMaps[strings.ToLower(strings.TrimSpace(level))]
+	if !ok {
+		return errors.New("logg: unknow level " + level)
+	}
+	log.SetLevel(v)
+	return nil
 }
 
 //SetLogFuncCallDepth setter
@@ -203,147 +916,535 @@ func (log *BaseLogger) EnableFuncCallDepath(d bool) {
 	log.enableFuncCallDepth = d
 }
 
+//SetUTC render the message times in UTC instead of their own location,
+//the local time for the level methods, in every appender and format
+func (log *BaseLogger) SetUTC(utc bool) {
+	var v int32
+	if utc {
+		v = 1
+	}
+	atomic.StoreInt32(&log.utc, v)
+}
+
+//SetSequence number the messages from 1, rendered as #0000123 at the start
+//of the text and "seq" in json and logfmt, so dropped lines show as gaps.
+//The number is taken and the message queued under one lock, so the messages
+//are written in number order unless SetWorkers is more than 1
+func (log *BaseLogger) SetSequence(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&log.sequence, v)
+}
+
+//sendNumbered send e, numbered when SetSequence is on
+func (log *BaseLogger) sendNumbered(e *Entry) {
+	if atomic.LoadInt32(&log.sequence) == 0 {
+		log.send(e)
+		return
+	}
+	log.seqLock.Lock()
+	log.seq++
+	e.Seq = log.seq
+	log.send(e)
+	log.seqLock.Unlock()
+}
+
+//SetVersionTag tag every message with v, like a release, rendered as
+//{v=1.4.2} at the end of the text and "version" in json and logfmt. An
+//empty v removes it
+func (log *BaseLogger) SetVersionTag(v string) {
+	log.versionTag.Store(v)
+}
+
+//EnableCallerFunc add the calling function as "func" to the json and logfmt
+//output, needs EnableFuncCallDepath(true)
+func (log *BaseLogger) EnableCallerFunc(b bool) {
+	log.callerFunc = b
+}
+
+//funcName the function at pc without its import path, like "logg.Func"
+func funcName(pc uintptr) string {
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return ""
+	}
+	name := fn.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
 //Fatal log.Fatal
 func (log *BaseLogger) Fatal(format string, v ...interface{}) {
-	if LevelFatal > log.level {
+	if log.skipped(LevelFatal) {
 		return
 	}
-	msg := fmt.Sprintf("[F] "+format, v...)
-	log.writeMsg(LevelFatal, msg)
+	log.writeMsg(LevelFatal, sprintf(format, v...), nil)
 }
 
 //Error log.Error
 func (log *BaseLogger) Error(format string, v ...interface{}) {
-	if LevelError > log.level {
+	if log.skipped(LevelError) {
 		return
 	}
-	msg := fmt.Sprintf("[E] "+format, v...)
-	log.writeMsg(LevelError, msg)
+	log.writeMsg(LevelError, sprintf(format, v...), nil)
 }
 
 //Warn log.Warn
 func (log *BaseLogger) Warn(format string, v ...interface{}) {
-	if LevelWarn > log.level {
+	if log.skipped(LevelWarn) {
 		return
 	}
-	msg := fmt.Sprintf("[W] "+format, v...)
-	log.writeMsg(LevelWarn, msg)
+	log.writeMsg(LevelWarn, sprintf(format, v...), nil)
 }
 
 //Info log.Info
 func (log *BaseLogger) Info(format string, v ...interface{}) {
-	if LevelInfo > log.level {
+	if log.skipped(LevelInfo) {
 		return
 	}
-	msg := fmt.Sprintf("[I] "+format, v...)
-	log.writeMsg(LevelInfo, msg)
+	log.writeMsg(LevelInfo, sprintf(format, v...), nil)
 }
 
 //Debug log.Debug
 func (log *BaseLogger) Debug(format string, v ...interface{}) {
-	if LevelDebug > log.level {
+	if log.skipped(LevelDebug) {
 		return
 	}
-	msg := fmt.Sprintf("[D] "+format, v...)
-	log.writeMsg(LevelDebug, msg)
+	log.writeMsg(LevelDebug, sprintf(format, v...), nil)
 }
 
-//Flush flush logger's msg
+//Flush flush logger's msg, a Flush racing with Close returns once the
+//workers are stopped and does nothing after it
 func (log *BaseLogger) Flush() {
+	log.flushSummaries(false)
 	if log.async {
-		log.singalChan <- "flush"
-		log.wg.Wait()
-		log.wg.Add(1)
+		log.signalLock.Lock()
+		if log.closed {
+			log.signalLock.Unlock()
+			return
+		}
+		if log.spill != nil {
+			log.spill.wait()
+		}
+		log.stopWorkers(flushMarker)
+		log.flush()
+		for i := 0; i < log.workers; i++ {
+			log.release <- struct{}{}
+		}
+		log.signalLock.Unlock()
 		return
 	}
 	log.flush()
 }
 
+//flush write what is left in msgChan then flush the appenders, the workers
+//must be stopped
 func (log *BaseLogger) flush() {
+	log.drain()
+	atomic.StoreInt32(&log.unflushed, 0)
+	log.lock.RLock()
+	for _, out := range log.appenders {
+		out.Flush()
+	}
+	log.lock.RUnlock()
+}
+
+//drain write what is left in msgChan, the workers must be stopped
+func (log *BaseLogger) drain() {
 	for {
 		if len(log.msgChan) > 0 {
 			m := <-log.msgChan
-			log.writeToAppender(m.when, m.msg, m.level)
+			log.writeToAppender(m)
 			log.logMsgPool.Put(m)
 			continue
 		}
 		break
 	}
+}
+
+//shutdown flush every appender before destroying any of them, appenders
+//depending on each other's buffers can rely on that order
+func (log *BaseLogger) shutdown() {
+	log.flush()
+	log.destroyAppenders()
+}
+
+func (log *BaseLogger) destroyAppenders() {
+	log.lock.Lock()
+	destroyNamed(log.appenders, fallbacksOf(nil))
+	log.appenders = nil
+	log.lock.Unlock()
+}
 
+//Rotate flush the logger then rotate every appender implementing Rotatable,
+//the first rotate error is returned
+func (log *BaseLogger) Rotate() error {
+	log.Flush()
+	log.lock.Lock()
+	defer log.lock.Unlock()
+	var rotateErr error
 	for _, out := range log.appenders {
-		out.Flush()
+		r, ok := out.Appender.(Rotatable)
+		if !ok {
+			continue
+		}
+		if err := r.Rotate(); err != nil && rotateErr == nil {
+			rotateErr = errors.New("logg: rotate appender " + out.name + " error " + err.Error())
+		}
+	}
+	return rotateErr
+}
+
+//Reopen flush the logger then reopen every appender implementing Reopenable,
+//the first reopen error is returned
+func (log *BaseLogger) Reopen() error {
+	log.Flush()
+	log.lock.Lock()
+	defer log.lock.Unlock()
+	var reopenErr error
+	for _, out := range log.appenders {
+		r, ok := out.Appender.(Reopenable)
+		if !ok {
+			continue
+		}
+		if err := r.Reopen(); err != nil && reopenErr == nil {
+			reopenErr = errors.New("logg: reopen appender " + out.name + " error " + err.Error())
+		}
 	}
+	return reopenErr
+}
 
+//HealthCheck probe the appenders implementing HealthChecker, the others are
+//reported healthy with a nil error. Results are keyed by appender name,
+//repeated names get a "#2", "#3"... suffix in attach order
+func (log *BaseLogger) HealthCheck() map[string]error {
+	log.lock.RLock()
+	defer log.lock.RUnlock()
+	health := make(map[string]error, len(log.appenders))
+	seen := make(map[string]int)
+	for _, out := range log.appenders {
+		key := out.name
+		if seen[out.name]++; seen[out.name] > 1 {
+			key += "#" + strconv.Itoa(seen[out.name])
+		}
+		var err error
+		if hc, ok := out.Appender.(HealthChecker); ok {
+			err = hc.HealthCheck()
+		}
+		health[key] = err
+	}
+	return health
 }
 
-//Close close the logger
-func (log *BaseLogger) Close() {
-	if log.async {
-		log.singalChan <- "close"
-		log.wg.Wait()
-	} else {
-		log.flush()
-		for _, out := range log.appenders {
-			out.Destroy()
+//OnRotate set fn as the rotate hook of the appenders implementing
+//RotateNotifier, the attached ones and the ones attached later. fn runs in
+//its own goroutine so it may upload or compress the rotated file without
+//blocking writes, its panics are recovered
+func (log *BaseLogger) OnRotate(fn func(path string)) {
+	log.rotateHook.Store(fn)
+	log.lock.RLock()
+	defer log.lock.RUnlock()
+	for _, out := range log.appenders {
+		if rn, ok := out.Appender.(RotateNotifier); ok {
+			rn.OnRotate(fn)
 		}
-		log.appenders = nil
 	}
-	close(log.msgChan)
-	close(log.singalChan)
 }
 
+//OnError set fn as the error hook of the attached appenders implementing
+//ErrorNotifier, fn runs in its own goroutine and its panics are recovered
+func (log *BaseLogger) OnError(fn func(err error)) {
+	log.lock.RLock()
+	defer log.lock.RUnlock()
+	for _, out := range log.appenders {
+		if en, ok := out.Appender.(ErrorNotifier); ok {
+			en.OnError(fn)
+		}
+	}
+}
+
+//OnDelete set fn as the delete hook of the attached appenders implementing
+//DeleteNotifier, fn runs in its own goroutine and its panics are recovered
+func (log *BaseLogger) OnDelete(fn func(path string)) {
+	log.lock.RLock()
+	defer log.lock.RUnlock()
+	for _, out := range log.appenders {
+		if dn, ok := out.Appender.(DeleteNotifier); ok {
+			dn.OnDelete(fn)
+		}
+	}
+}
+
+//ReopenOnSIGHUP install a SIGHUP handler calling Reopen, the returned func
+//uninstall it
+func (log *BaseLogger) ReopenOnSIGHUP() (stop func()) {
+	sigChan := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigChan, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-sigChan:
+				if err := log.Reopen(); err != nil {
+					fmt.Fprintf(os.Stderr, "logg: SIGHUP reopen error:%v\n", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigChan)
+		close(done)
+	}
+}
+
+//Close write the pending messages, flush every appender then destroy them,
+//no Destroy is called before all the Flush calls returned. Later calls do nothing
+func (log *BaseLogger) Close() {
+	log.closeOnce.Do(func() {
+		log.pauseLock.Lock()
+		deferred := log.deferred
+		log.pauseLock.Unlock()
+		if deferred {
+			log.Start()
+		}
+		log.flushSummaries(true)
+		log.signalLock.Lock()
+		if log.async {
+			if log.spill != nil {
+				log.spill.wait()
+			}
+			log.stopWorkers(closeMarker)
+		}
+		log.closed = true
+		log.signalLock.Unlock()
+		if log.spill != nil {
+			log.drain()
+			log.spill.stop()
+		}
+		log.shutdown()
+		close(log.msgChan)
+		close(log.done)
+	})
+}
+
+//LoadConfig load level and appenders from an ini file, see readme.md
 func (log *BaseLogger) LoadConfig(filename string) *BaseLogger {
-	cnf := config.NewIniConfig()
-	err := cnf.Parse(filename)
+	cnf, err := parseConfig(filename)
 	if err != nil {
 		panic("LoadConfig: Parse filename error " + filename)
 	}
-	strLevel := cnf.String("logg.root.level")
-	if v, ok := levelStrMaps[strLevel]; ok {
-		log.SetLevel(v)
+	cnf.applyRoot(log)
+	for _, spec := range cnf.appenders {
+		log.setAppender(spec.name, spec.config, true)
 	}
-	if callFile, err := cnf.Bool("logg.root.callfile"); err == nil {
-		log.EnableFuncCallDepath(callFile)
+	return log
+}
+
+//LoadPolicy how LoadConfigE handles appenders failing to initialize
+type LoadPolicy int
+
+const (
+	//LoadFailFast return the first appender error, nothing is applied
+	LoadFailFast LoadPolicy = iota
+	//LoadBestEffort attach the appenders that succeeded and return a
+	//*LoadConfigError listing the others
+	LoadBestEffort
+)
+
+//LoadConfigError the appender errors of a LoadBestEffort LoadConfigE or of
+//SetAppenders
+type LoadConfigError struct {
+	Errs []error
+	op   string //the failed call, LoadConfigE when empty
+}
+
+func (e *LoadConfigError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
 	}
+	op := e.op
+	if len(op) == 0 {
+		op = "LoadConfigE"
+	}
+	return "logg: " + op + " " + strconv.Itoa(len(e.Errs)) + " appender error(s): " + strings.Join(msgs, "; ")
+}
 
-	strStdAppender := cnf.String("logg.appender.stdout")
-	if strStdAppender == "console" {
-		strL := cnf.String("logg.appender.stdout.level")
-		if v, ok := levelStrMaps[strL]; ok {
-			log.SetAppender("console", `{"level":`+strconv.Itoa(v)+`}`)
-		} else {
-			log.SetAppender("console", ``)
+//LoadConfigE is LoadConfig returning errors instead of panicking or ignoring
+//them, appender errors are handled according to policy
+func (log *BaseLogger) LoadConfigE(filename string, policy LoadPolicy) error {
+	cnf, err := parseConfig(filename)
+	if err != nil {
+		return errors.New("logg: LoadConfigE parse " + filename + " error " + err.Error())
+	}
+	if policy == LoadBestEffort {
+		cnf.applyRoot(log)
+		var errs []error
+		for _, spec := range cnf.appenders {
+			if err := log.setAppender(spec.name, spec.config, true); err != nil {
+				errs = append(errs, errors.New(spec.name+": "+err.Error()))
+			}
+		}
+		if len(errs) > 0 {
+			return &LoadConfigError{Errs: errs}
 		}
+		return nil
+	}
 
-	} else if strStdAppender == "file" {
-		strConf := `{`
-		strFile := cnf.String("logg.appender.stdout.file")
-		if len(strFile) > 0 {
-			strConf = strConf + `"filename":"` + strFile + `",`
+	log.lock.RLock()
+	created := append([]*nameAppender(nil), log.appenders...)
+	log.lock.RUnlock()
+	existing := len(created)
+	for _, spec := range cnf.appenders {
+		err := checkDuplicate(created, spec.name)
+		var out Appender
+		if err == nil {
+			out, err = newAppender(spec.name, spec.config)
 		}
-		strL := cnf.String("logg.appender.stdout.level")
-		if v, ok := levelStrMaps[strL]; ok {
-			strConf = strConf + `"level":` + strconv.Itoa(v) + `,`
+		if err != nil {
+			for _, c := range created[existing:] {
+				c.Destroy()
+			}
+			return errors.New("logg: LoadConfigE " + spec.name + ": " + err.Error())
 		}
+		created = append(created, &nameAppender{name: spec.name, Appender: out, config: spec.config, loaded: true})
+	}
+	cnf.applyRoot(log)
+	log.lock.Lock()
+	for _, out := range created[existing:] {
+		log.attach(out.Appender)
+		log.appenders = append(log.appenders, out)
+	}
+	log.lock.Unlock()
+	return nil
+}
 
-		if maxday, err := cnf.Int("logg.appender.stdout.maxday"); err == nil {
-			strConf = strConf + `"maxday":` + strconv.Itoa(maxday) + `,`
-		}
+//ReloadConfig parse filename again and reconcile the running logger: the level
+//is updated, new appenders are added and the ones created by a previous
+//LoadConfig/ReloadConfig but no longer configured are flushed and destroyed.
+//Unchanged appenders are kept open, the first appender error is returned
+func (log *BaseLogger) ReloadConfig(filename string) error {
+	cnf, err := parseConfig(filename)
+	if err != nil {
+		return errors.New("logg: ReloadConfig parse " + filename + " error " + err.Error())
+	}
 
-		if maxsize, err := cnf.Int("logg.appender.stdout.maxsize"); err == nil {
-			strConf = strConf + `"maxsize":` + strconv.Itoa(maxsize) + `,`
+	log.lock.RLock()
+	current := log.appenders
+	log.lock.RUnlock()
+	reused := make(map[*nameAppender]bool)
+	var loaded []*nameAppender
+	var reloadErr error
+	for _, spec := range cnf.appenders {
+		var keep *nameAppender
+		for _, out := range current {
+			if out.loaded && !reused[out] && out.name == spec.name && out.config == spec.config {
+				keep = out
+				break
+			}
 		}
-		if daily, err := cnf.Bool("logg.appender.stdout.daily"); err == nil {
-			strConf = strConf + `"daily":` + strconv.FormatBool(daily) + `,`
+		if keep != nil {
+			reused[keep] = true
+			loaded = append(loaded, keep)
+			continue
 		}
-		if rotate, err := cnf.Bool("logg.appender.stdout.rotate"); err == nil {
-			strConf = strConf + `"rotate":` + strconv.FormatBool(rotate) + `,`
+		out, err := newAppender(spec.name, spec.config)
+		if err != nil {
+			if reloadErr == nil {
+				reloadErr = err
+			}
+			continue
+		}
+		log.attach(out)
+		loaded = append(loaded, &nameAppender{name: spec.name, Appender: out, config: spec.config, loaded: true})
+	}
+
+	log.lock.Lock()
+	var appenders, removed []*nameAppender
+	for _, out := range log.appenders {
+		if !out.loaded {
+			appenders = append(appenders, out)
+		} else if !reused[out] {
+			removed = append(removed, out)
 		}
+	}
+	log.appenders = append(appenders, loaded...)
+	inUse := fallbacksOf(log.appenders)
+	log.lock.Unlock()
 
-		if len(strConf) > len(`{`) {
-			strConf = strConf[0:len(strConf)-1] + `}`
+	for _, out := range removed {
+		out.Flush()
+	}
+	destroyNamed(removed, inUse)
+	cnf.applyRoot(log)
+	return reloadErr
+}
+
+//WatchConfig poll filename every interval and ReloadConfig when its
+//modification time changes, the returned func stops watching
+func (log *BaseLogger) WatchConfig(filename string, interval time.Duration) (stop func()) {
+	var lastMod time.Time
+	if info, err := os.Stat(filename); err == nil {
+		lastMod = info.ModTime()
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				info, err := os.Stat(filename)
+				if err != nil || info.ModTime().Equal(lastMod) {
+					continue
+				}
+				lastMod = info.ModTime()
+				if err := log.ReloadConfig(filename); err != nil {
+					fmt.Fprintf(os.Stderr, "logg: WatchConfig reload error:%v\n", err)
+				}
+			case <-done:
+				return
+			}
 		}
-		log.SetAppender("file", strConf)
+	}()
+	return func() {
+		close(done)
+	}
+}
+
+type appenderSpec struct {
+	name   string
+	config string
+}
+
+type loggerConfig struct {
+	level       int
+	hasLevel    bool
+	callFile    bool
+	hasCallFile bool
+	appenders   []appenderSpec
+}
+
+func parseConfig(filename string) (*loggerConfig, error) {
+	cnf := config.NewIniConfig()
+	if err := cnf.Parse(filename); err != nil {
+		return nil, err
+	}
+	lc := &loggerConfig{}
+	lc.level, lc.hasLevel = levelStrMaps[cnf.String("logg.root.level")]
+	if callFile, err := cnf.Bool("logg.root.callfile"); err == nil {
+		lc.callFile, lc.hasCallFile = callFile, true
+	}
+
+	strStdAppender := cnf.String("logg.appender.stdout")
+	if strStdAppender == "console" || strStdAppender == "file" {
+		lc.appenders = append(lc.appenders, appenderSpec{strStdAppender, appenderConfig(cnf, strStdAppender, "logg.appender.stdout")})
 	}
 
 	//other appenders
@@ -355,48 +1456,71 @@ func (log *BaseLogger) LoadConfig(filename string) *BaseLogger {
 
 		strPreKey := "logg.appender." + name
 		appenderName := cnf.String(strPreKey)
-		if appenderName == "console" {
-			if v, ok := levelStrMaps[cnf.String(strPreKey+".level")]; ok {
-				log.SetAppender("console", `{"level":`+strconv.Itoa(v)+`}`)
-			} else {
-				log.SetAppender("console", ``)
-			}
-
-		} else if appenderName == "file" {
-			strConf := `{`
-			strFile := cnf.String(strPreKey + ".file")
-			if len(strFile) > 0 {
-				strConf = strConf + `"filename":"` + strFile + `",`
-			}
-			strL := cnf.String(strPreKey + ".level")
-			if v, ok := levelStrMaps[strL]; ok {
-				strConf = strConf + `"level":` + strconv.Itoa(v) + `,`
-			}
+		if appenderName == "console" || appenderName == "file" {
+			lc.appenders = append(lc.appenders, appenderSpec{appenderName, appenderConfig(cnf, appenderName, strPreKey)})
+		}
+	}
+	return lc, nil
+}
 
-			if maxday, err := cnf.Int(strPreKey + ".maxday"); err == nil {
-				strConf = strConf + `"maxday":` + strconv.Itoa(maxday) + `,`
-			}
+func (lc *loggerConfig) applyRoot(log *BaseLogger) {
+	if lc.hasLevel {
+		log.SetLevel(lc.level)
+	}
+	if lc.hasCallFile {
+		log.EnableFuncCallDepath(lc.callFile)
+	}
+}
 
-			if maxsize, err := cnf.Int(strPreKey + ".maxsize"); err == nil {
-				strConf = strConf + `"maxsize":` + strconv.Itoa(maxsize) + `,`
-			}
-			if daily, err := cnf.Bool(strPreKey + ".daily"); err == nil {
-				strConf = strConf + `"daily":` + strconv.FormatBool(daily) + `,`
-			}
-			if rotate, err := cnf.Bool(strPreKey + ".rotate"); err == nil {
-				strConf = strConf + `"rotate":` + strconv.FormatBool(rotate) + `,`
-			}
+type iniConfig interface {
+	String(key string) string
+	Int(key string) (int, error)
+	Bool(key string) (bool, error)
+}
 
-			if len(strConf) > len(`{`) {
-				strConf = strConf[0:len(strConf)-1] + `}`
-			}
-			log.SetAppender("file", strConf)
+//appenderConfig build the json config of the console or file appender
+//declared under strPreKey
+func appenderConfig(cnf iniConfig, appenderName string, strPreKey string) string {
+	strConf := `{`
+	if appenderName == "file" {
+		strFile := cnf.String(strPreKey + ".file")
+		if len(strFile) > 0 {
+			strConf = strConf + `"filename":"` + strFile + `",`
+		}
+	}
+	strL := cnf.String(strPreKey + ".level")
+	if minL := cnf.String(strPreKey + ".minlevel"); len(minL) > 0 {
+		strL = minL
+	}
+	if v, ok := levelStrMaps[strL]; ok {
+		strConf = strConf + `"level":` + strconv.Itoa(v) + `,`
+	}
+	if v, ok := levelStrMaps[cnf.String(strPreKey+".maxlevel")]; ok {
+		strConf = strConf + `"maxlevel":` + strconv.Itoa(v) + `,`
+	}
 
+	if appenderName == "file" {
+		if maxdays, err := cnf.Int(strPreKey + ".maxdays"); err == nil {
+			strConf = strConf + `"maxdays":` + strconv.Itoa(maxdays) + `,`
+		} else if maxday, err := cnf.Int(strPreKey + ".maxday"); err == nil {
+			strConf = strConf + `"maxdays":` + strconv.Itoa(maxday) + `,`
 		}
 
+		if maxsize, err := cnf.Int(strPreKey + ".maxsize"); err == nil {
+			strConf = strConf + `"maxsize":` + strconv.Itoa(maxsize) + `,`
+		}
+		if daily, err := cnf.Bool(strPreKey + ".daily"); err == nil {
+			strConf = strConf + `"daily":` + strconv.FormatBool(daily) + `,`
+		}
+		if rotate, err := cnf.Bool(strPreKey + ".rotate"); err == nil {
+			strConf = strConf + `"rotate":` + strconv.FormatBool(rotate) + `,`
+		}
 	}
 
-	return log
+	if len(strConf) == len(`{`) {
+		return ``
+	}
+	return strConf[0:len(strConf)-1] + `}`
 }
 
 var levelStrMaps = make(map[string]int)
@@ -1,7 +1,21 @@
 package logg
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestLog(t *testing.T) {
@@ -15,3 +29,1170 @@ func TestLog(t *testing.T) {
 	log.Flush()
 	log.Close()
 }
+
+func addRecorder(log *BaseLogger, name string) *Recorder {
+	r := NewRecorder()
+	log.appenders = append(log.appenders, &nameAppender{name: name, Appender: r})
+	return r
+}
+
+func TestDeferStart(t *testing.T) {
+	log := NewLogger(10)
+	log.Defer()
+	log.Async()
+	log.Info("starting %d", 1)
+	log.Warn("starting %d", 2)
+	first := &Recorder{}
+	log.AddAppender("first", first)
+	log.Info("starting %d", 3)
+	second := &Recorder{}
+	log.AddAppender("second", second)
+	log.Flush()
+	if len(first.Lines()) != 0 {
+		t.Fatalf("written before Start: %q", first.Lines())
+	}
+	log.Start()
+	log.Info("started")
+	log.Close()
+	want := []string{"[I] starting 1", "[W] starting 2", "[I] starting 3", "[I] started"}
+	for _, got := range [][]string{first.Lines(), second.Lines()} {
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestDeferCloseBeforeStart(t *testing.T) {
+	log := NewLogger(10)
+	log.Defer()
+	log.Async()
+	r := addRecorder(log, "record")
+	log.Info("starting %d", 1)
+	log.Warn("starting %d", 2)
+	log.Close()
+	if got := r.Lines(); strings.Join(got, "|") != "[I] starting 1|[W] starting 2" {
+		t.Fatalf("held messages lost on Close: %q", got)
+	}
+}
+
+//slowInitAppender block Init until release is closed, like a file appender
+//retrying its open
+type slowInitAppender struct {
+	Recorder
+}
+
+var slowInitRelease chan struct{} //made by each test using it
+
+func (s *slowInitAppender) Init(config string) error {
+	<-slowInitRelease
+	return nil
+}
+
+func init() {
+	RegisterAppender("slowinit_test", func() Appender { return &slowInitAppender{} })
+}
+
+func TestSetAppenderInitUnlocked(t *testing.T) {
+	slowInitRelease = make(chan struct{})
+	log := NewLogger(10)
+	r := addRecorder(log, "record")
+	set := make(chan error)
+	go func() { set <- log.SetAppender("slowinit_test", "") }()
+	logged := make(chan struct{})
+	go func() {
+		log.Info("while init")
+		log.Flush()
+		close(logged)
+	}()
+	select {
+	case <-logged:
+	case <-time.After(time.Second):
+		t.Fatal("logging blocked by an appender init")
+	}
+	close(slowInitRelease)
+	if err := <-set; err != nil {
+		t.Fatal(err)
+	}
+	log.Close()
+	if got := r.Lines(); len(got) != 1 {
+		t.Fatalf("unexpected lines %q", got)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	log := NewLogger(100)
+	r := addRecorder(log, "record")
+	log.Async()
+	log.Info("before pause")
+	log.Pause()
+	log.Info("dropped while paused")
+	log.Flush()
+	if got := r.Lines(); len(got) != 1 {
+		t.Fatalf("paused logger wrote %q", got)
+	}
+	log.SetPauseBuffer(1)
+	log.Info("kept while paused")
+	log.Info("over the pause buffer")
+	log.Resume()
+	log.Info("after resume")
+	log.Close()
+	want := []string{"[I] before pause", "[I] kept while paused", "[I] after resume"}
+	got := r.Lines()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+//reentrantAppender log once from its first write
+type reentrantAppender struct {
+	Recorder
+	log  *BaseLogger
+	once sync.Once
+}
+
+func (r *reentrantAppender) WriteMsg(when time.Time, msg string, level int) error {
+	r.Recorder.WriteMsg(when, msg, level)
+	r.once.Do(func() { r.log.Info("logged by the appender") })
+	return nil
+}
+
+func TestResumeUnlocked(t *testing.T) {
+	log := NewLogger(0)
+	r := &reentrantAppender{log: log}
+	log.AddAppender("reentrant", r)
+	log.SetPauseBuffer(2)
+	log.Pause()
+	log.Info("first kept")
+	log.Info("second kept")
+	done := make(chan struct{})
+	go func() {
+		log.Resume()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Resume deadlocked on a message logged while replaying")
+	}
+	log.Info("after resume")
+	want := []string{"[I] first kept", "[I] second kept", "[I] logged by the appender", "[I] after resume"}
+	if got := r.Lines(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+type ctxKey struct{}
+
+func TestContextExtractor(t *testing.T) {
+	log := NewLogger(10)
+	r := addRecorder(log, "record")
+	log.AddContextExtractor(func(ctx context.Context) []Field {
+		if id, ok := ctx.Value(ctxKey{}).(string); ok {
+			return []Field{{"request_id", id}}
+		}
+		return nil
+	})
+	log.InfoContext(context.WithValue(context.Background(), ctxKey{}, "r1"), "with id")
+	log.InfoContext(context.Background(), "without id")
+	log.Close()
+	got := r.Lines()
+	if len(got) != 2 || got[0] != "[I] with id request_id=r1" || got[1] != "[I] without id" {
+		t.Fatalf("unexpected lines %q", got)
+	}
+}
+
+func TestContextLevel(t *testing.T) {
+	log := NewLogger(10)
+	log.SetLevel(LevelInfo)
+	r := addRecorder(log, "record")
+	debug := WithLevel(context.Background(), LevelDebug)
+	log.DebugContext(debug, "traced")
+	log.DebugContext(context.Background(), "filtered")
+	log.InfoContext(WithLevel(context.Background(), LevelWarn), "quiet")
+	log.Debug("global")
+	log.Close()
+	if got := r.Lines(); len(got) != 1 || got[0] != "[D] traced" {
+		t.Fatalf("unexpected lines %q", got)
+	}
+}
+
+func TestStatsFiltered(t *testing.T) {
+	log := NewLogger(10)
+	log.SetLevel(LevelWarn)
+	r := addRecorder(log, "record")
+	log.Debug("d")
+	log.Debugw("d", "k", 1)
+	log.With(Int("id", 1)).Debug("d")
+	log.InfoContext(context.Background(), "i")
+	log.DebugContext(WithLevel(context.Background(), LevelDebug), "kept")
+	log.Warn("w")
+	log.Close()
+	s := log.Stats()
+	if s.Filtered[LevelDebug] != 3 || s.Filtered[LevelInfo] != 1 || s.Filtered[LevelWarn] != 0 {
+		t.Fatalf("filtered %v", s.Filtered)
+	}
+	if got := r.Lines(); len(got) != 2 {
+		t.Fatalf("unexpected lines %q", got)
+	}
+}
+
+func TestSequence(t *testing.T) {
+	log := NewLogger(100)
+	log.SetLevel(LevelInfo)
+	r := addRecorder(log, "record")
+	log.SetSequence(true)
+	log.Async()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				log.Info("g%d %d", g, i)
+				log.Debug("filtered")
+			}
+		}(g)
+	}
+	wg.Wait()
+	log.Close()
+	got := r.Lines()
+	if len(got) != 100 {
+		t.Fatalf("%d lines", len(got))
+	}
+	last := make([]int, 4)
+	for n, line := range got {
+		var seq, g, i int
+		if n, _ := fmt.Sscanf(line, "#%07d [I] g%d %d", &seq, &g, &i); n != 3 {
+			t.Fatalf("unexpected line %q", line)
+		}
+		if seq != n+1 {
+			t.Fatalf("line %d numbered %d: %q", n, seq, line)
+		}
+		if i != last[g] {
+			t.Fatalf("goroutine %d out of order: %q", g, line)
+		}
+		last[g]++
+	}
+	e := &Entry{Level: LevelInfo, Msg: "m", Seq: 12345678}
+	if got := string(e.appendText(nil)); got != "#12345678 [I] m" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestLoadConfigLevelRange(t *testing.T) {
+	dir := t.TempDir()
+	errFile := filepath.Join(dir, "error_only.log")
+	ini := filepath.Join(dir, "range.ini")
+	content := "logg.root.level = debug\n" +
+		"logg.appender.stdout = console\n" +
+		"logg.appender.stdout.level = debug\n" +
+		"logg.appender = \"A1\"\n" +
+		"logg.appender.A1 = file\n" +
+		"logg.appender.A1.file = " + errFile + "\n" +
+		"logg.appender.A1.minlevel = error\n" +
+		"logg.appender.A1.maxlevel = error\n"
+	if err := os.WriteFile(ini, []byte(content), 0660); err != nil {
+		t.Fatal(err)
+	}
+	log := NewLogger(10).LoadConfig(ini)
+	if len(log.appenders) != 2 {
+		t.Fatalf("expected console and file appenders, got %d", len(log.appenders))
+	}
+	console := log.appenders[0].Appender.(*consoleWriter)
+	if console.Level != LevelDebug || console.MaxLevel != LevelFatal {
+		t.Fatalf("console should take every level, got %d..%d", console.Level, console.MaxLevel)
+	}
+	log.Debug("range debug")
+	log.Error("range error")
+	log.Fatal("range fatal")
+	log.Close()
+	data, err := os.ReadFile(errFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 || !strings.HasSuffix(lines[0], "[E] range error") {
+		t.Fatalf("error-only file got %q", data)
+	}
+}
+
+func newDiscardLogger() *BaseLogger {
+	log := NewLogger(1024)
+	log.appenders = append(log.appenders, &nameAppender{name: "console", Appender: &consoleWriter{lg: newLogWriter(io.Discard), Level: LevelDebug}})
+	return log
+}
+
+//Entries are pooled and appenders render into reused buffers: BenchmarkInfo
+//went from 6 allocs/op to 1 (the fmt.Sprintf result), BenchmarkInfoNoArgs to 0
+func BenchmarkInfo(b *testing.B) {
+	log := newDiscardLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info("hello %s", "world")
+	}
+}
+
+func BenchmarkInfoNoArgs(b *testing.B) {
+	log := newDiscardLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info("hello world")
+	}
+}
+
+func BenchmarkAsyncInfo(b *testing.B) {
+	log := newDiscardLogger().Async()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info("hello %s", "world")
+	}
+	log.Flush()
+	b.StopTimer()
+	log.Close()
+}
+
+func TestTextOutput(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLogger(10)
+	log.appenders = append(log.appenders, &nameAppender{name: "console", Appender: &consoleWriter{lg: newLogWriter(&buf), Level: LevelDebug}})
+	log.Info("100%% sure")
+	log.Info("plain")
+	log.Warn("x %d", 1)
+	log.With(Field{"k", "v"}).Error("with field")
+	log.EnableFuncCallDepath(true)
+	log.Debug("with caller")
+	log.Close()
+	want := []string{"[I] 100% sure", "[I] plain", "[W] x 1", "[E] with field k=v", "[D][log_test.go:"}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %q", lines)
+	}
+	for i, line := range lines {
+		if _, err := time.ParseInLocation(timeLayout, line[:len(timeLayout)], time.Local); err != nil {
+			t.Fatalf("line %q has no timestamp: %v", line, err)
+		}
+		if !strings.HasPrefix(line[len(timeLayout):], want[i]) {
+			t.Fatalf("line %q, want %q after timestamp", line, want[i])
+		}
+	}
+	if !strings.HasSuffix(lines[4], "] with caller") {
+		t.Fatalf("bad caller line %q", lines[4])
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.log")
+	second := filepath.Join(dir, "second.log")
+	ini := filepath.Join(dir, "reload.ini")
+	write := func(content string) {
+		if err := os.WriteFile(ini, []byte(content), 0660); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("logg.root.level = debug\n" +
+		"logg.appender = \"A1;A2\"\n" +
+		"logg.appender.A1 = file\n" +
+		"logg.appender.A1.file = " + first + "\n" +
+		"logg.appender.A2 = file\n" +
+		"logg.appender.A2.file = " + second + "\n")
+	log := NewLogger(10).LoadConfig(ini)
+	r := addRecorder(log, "record")
+	kept := log.appenders[1]
+	log.Debug("before reload")
+
+	write("logg.root.level = warn\n" +
+		"logg.appender = \"A2\"\n" +
+		"logg.appender.A2 = file\n" +
+		"logg.appender.A2.file = " + second + "\n")
+	if err := log.ReloadConfig(ini); err != nil {
+		t.Fatal(err)
+	}
+	if log.Level() != LevelWarn {
+		t.Fatalf("level not reloaded, got %d", log.Level())
+	}
+	if len(log.appenders) != 2 || log.appenders[0].Appender != r || log.appenders[1] != kept {
+		t.Fatalf("unexpected appenders after reload %v", log.appenders)
+	}
+	log.Info("filtered after reload")
+	log.Warn("after reload")
+	log.Close()
+
+	data, _ := os.ReadFile(first)
+	if strings.Contains(string(data), "after reload") {
+		t.Fatalf("removed appender still written: %q", data)
+	}
+	data, _ = os.ReadFile(second)
+	if !strings.Contains(string(data), "before reload") || !strings.Contains(string(data), "[W] after reload") ||
+		strings.Contains(string(data), "filtered") {
+		t.Fatalf("kept appender got %q", data)
+	}
+	if got := r.Lines(); len(got) != 2 {
+		t.Fatalf("programmatic appender must survive reload, got %q", got)
+	}
+}
+
+func TestEnableAudit(t *testing.T) {
+	dir := t.TempDir()
+	audit := filepath.Join(dir, "audit.log")
+	log := NewLogger(10)
+	if err := log.EnableAudit(audit); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.SetAppender("file", `{"filename":"`+filepath.Join(dir, "main.log")+`"}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("not audited")
+	log.Warn("audited warn")
+	if err := log.RemoveAppender("file"); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.RemoveAppender("audit"); err == nil {
+		t.Fatal("audit appender must not be removable")
+	}
+	log.Error("audited error")
+	log.Close()
+	data, err := os.ReadFile(audit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "[W] audited warn") || !strings.HasSuffix(lines[1], "[E] audited error") {
+		t.Fatalf("unexpected audit content %q", data)
+	}
+}
+
+func TestLoadConfigEPolicies(t *testing.T) {
+	dir := t.TempDir()
+	ini := filepath.Join(dir, "broken.ini")
+	content := "logg.root.level = warn\n" +
+		"logg.appender = \"A1;A2\"\n" +
+		"logg.appender.A1 = file\n" +
+		"logg.appender.A1.file = " + filepath.Join(dir, "good.log") + "\n" +
+		"logg.appender.A2 = file\n" +
+		"logg.appender.A2.file = " + filepath.Join(dir, "missing", "bad.log") + "\n"
+	if err := os.WriteFile(ini, []byte(content), 0660); err != nil {
+		t.Fatal(err)
+	}
+
+	log := NewLogger(10)
+	if err := log.LoadConfigE(ini, LoadFailFast); err == nil {
+		t.Fatal("fail-fast should return the broken appender error")
+	}
+	if len(log.appenders) != 0 || log.Level() != LevelDebug {
+		t.Fatalf("fail-fast applied the config: %d appenders, level %d", len(log.appenders), log.Level())
+	}
+
+	err := log.LoadConfigE(ini, LoadBestEffort)
+	lerr, ok := err.(*LoadConfigError)
+	if !ok || len(lerr.Errs) != 1 {
+		t.Fatalf("best-effort should return one appender error, got %v", err)
+	}
+	if len(log.appenders) != 1 || log.Level() != LevelWarn {
+		t.Fatalf("best-effort should attach the good appender: %d appenders, level %d", len(log.appenders), log.Level())
+	}
+	log.Close()
+}
+
+func TestSetLevelFor(t *testing.T) {
+	log := NewLogger(10)
+	log.SetLevel(LevelInfo)
+	log.SetLevelFor(LevelWarn, time.Hour)
+	log.SetLevelFor(LevelDebug, 20*time.Millisecond)
+	if log.Level() != LevelDebug {
+		t.Fatalf("level %d, want the last SetLevelFor", log.Level())
+	}
+	deadline := time.Now().Add(time.Second)
+	for log.Level() != LevelInfo && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if log.Level() != LevelInfo {
+		t.Fatalf("level %d not restored", log.Level())
+	}
+
+	log.SetLevelFor(LevelDebug, 20*time.Millisecond)
+	log.SetLevel(LevelError)
+	time.Sleep(50 * time.Millisecond)
+	if log.Level() != LevelError {
+		t.Fatalf("SetLevel should cancel the restoration, level %d", log.Level())
+	}
+}
+
+type orderAppender struct {
+	name  string
+	calls *[]string
+}
+
+func (o *orderAppender) Init(config string) error { return nil }
+
+func (o *orderAppender) WriteMsg(when time.Time, msg string, level int) error { return nil }
+
+func (o *orderAppender) Flush() { *o.calls = append(*o.calls, "flush "+o.name) }
+
+func (o *orderAppender) Destroy() { *o.calls = append(*o.calls, "destroy "+o.name) }
+
+func TestSetAppenders(t *testing.T) {
+	dir := t.TempDir()
+	log := NewLogger(10)
+	defer log.Close()
+	err := log.SetAppenders([]AppenderSpec{
+		{Name: "file", Config: `{"filename":"` + filepath.Join(dir, "a.log") + `"}`},
+		{Name: "file", Config: `{"filename":"` + filepath.Join(dir, "b.log") + `","format":"json"}`},
+		{Name: "console", Config: `{"target":"stderr","level":0,"maxlevel":1}`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log.appenders) != 3 {
+		t.Fatalf("%d appenders attached", len(log.appenders))
+	}
+	log.Info("to both files")
+	log.Flush()
+	for _, name := range []string{"a.log", "b.log"} {
+		if data, _ := os.ReadFile(filepath.Join(dir, name)); !strings.Contains(string(data), "to both files") {
+			t.Fatalf("%s got %q", name, data)
+		}
+	}
+
+	err = log.SetAppenders([]AppenderSpec{
+		{Name: "missing", Config: ``},
+		{Name: "file", Config: `{"filename":"` + filepath.Join(dir, "c.log") + `"}`},
+		{Name: "file", Config: `{}`},
+	})
+	var loadErr *LoadConfigError
+	if !errors.As(err, &loadErr) || len(loadErr.Errs) != 2 {
+		t.Fatalf("got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "logg: SetAppenders 2 appender error(s): missing: ") {
+		t.Fatalf("error %q", err)
+	}
+	if len(log.appenders) != 4 {
+		t.Fatalf("the valid spec was not attached, %d appenders", len(log.appenders))
+	}
+}
+
+func TestNoAppenders(t *testing.T) {
+	for _, channelLen := range []int{0, 10} {
+		for _, workers := range []int{1, 3} {
+			log := NewLogger(channelLen)
+			log.SetWorkers(workers)
+			log.Async()
+			log.Flush()
+			for i := 0; i < 50; i++ {
+				log.Info("nowhere %d", i)
+				log.With(Field{"i", i}).Debug("nowhere")
+			}
+			log.Flush()
+			if n := log.PendingCount(); n != 0 {
+				t.Fatalf("%d messages pending after Flush", n)
+			}
+			done := make(chan struct{})
+			go func() {
+				log.Close()
+				log.Close()
+				log.Flush()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(2 * time.Second):
+				t.Fatalf("Close hangs with channel %d and %d workers", channelLen, workers)
+			}
+		}
+	}
+	log := NewLogger(10)
+	log.Info("sync logger")
+	log.Flush()
+	log.Close()
+}
+
+func TestFlushAppender(t *testing.T) {
+	var calls []string
+	filename := filepath.Join(t.TempDir(), "flush.log.gz")
+	log := NewLogger(10)
+	defer log.Close()
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","livecompress":true}`); err != nil {
+		t.Fatal(err)
+	}
+	log.AddAppender("other", &orderAppender{"other", &calls})
+	log.Info("flushed")
+	if err := log.FlushAppender("file"); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(data), "[I] flushed\n") {
+		t.Fatalf("file got %q", data)
+	}
+	if len(calls) != 0 {
+		t.Fatalf("other appender touched: %q", calls)
+	}
+	if err := log.FlushAppender("missing"); err == nil {
+		t.Fatal("FlushAppender of a missing appender should fail")
+	}
+}
+
+func TestSetFlushEveryN(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "every.log.gz")
+	log := NewLogger(10)
+	defer log.Close()
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","livecompress":true}`); err != nil {
+		t.Fatal(err)
+	}
+	log.SetFlushEveryN(5)
+	read := func() string {
+		file, err := os.Open(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		zr, err := gzip.NewReader(file)
+		if err == io.EOF {
+			return ""
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(zr)
+		return string(data)
+	}
+	for i := 1; i <= 4; i++ {
+		log.Info("message %d", i)
+	}
+	if data := read(); strings.Contains(data, "message 4") {
+		t.Fatalf("flushed before the fifth message: %q", data)
+	}
+	log.Info("message 5")
+	if data := read(); !strings.HasSuffix(data, "[I] message 5\n") || strings.Count(data, "message") != 5 {
+		t.Fatalf("file after the fifth message got %q", data)
+	}
+}
+
+func TestFlushCloseRace(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		log := NewLogger(100)
+		log.AddAppender("count", &countAppender{})
+		log.SetWorkers(2)
+		log.Async()
+		for j := 0; j < 20; j++ {
+			log.Info("msg %d", j)
+		}
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			log.Flush()
+		}()
+		go func() {
+			defer wg.Done()
+			log.Close()
+		}()
+		finished := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(finished)
+		}()
+		select {
+		case <-finished:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Flush and Close hang")
+		}
+		log.Flush()
+	}
+}
+
+func TestCloseFlushesBeforeDestroy(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		var calls []string
+		log := NewLogger(10)
+		log.AddAppender("a", &orderAppender{"a", &calls})
+		log.AddAppender("b", &orderAppender{"b", &calls})
+		if async {
+			log.Async()
+		}
+		log.Info("msg")
+		log.Close()
+		want := []string{"flush a", "flush b", "destroy a", "destroy b"}
+		if strings.Join(calls, ",") != strings.Join(want, ",") {
+			t.Fatalf("async %v: calls %q, want %q", async, calls, want)
+		}
+	}
+}
+
+type countAppender struct {
+	n int64
+}
+
+func (c *countAppender) Init(config string) error { return nil }
+
+func (c *countAppender) WriteMsg(when time.Time, msg string, level int) error {
+	atomic.AddInt64(&c.n, 1)
+	return nil
+}
+
+func (c *countAppender) Flush() {}
+
+func (c *countAppender) Destroy() {}
+
+func TestSetWorkers(t *testing.T) {
+	log := NewLogger(64)
+	counter := &countAppender{}
+	log.AddAppender("count", counter)
+	log.SetWorkers(4)
+	log.Async()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				log.Info("msg %d", i)
+			}
+		}()
+	}
+	wg.Wait()
+	log.Flush()
+	if n := atomic.LoadInt64(&counter.n); n != 8000 {
+		t.Fatalf("after Flush %d messages written, want 8000", n)
+	}
+	log.Info("last")
+	log.Close()
+	if n := atomic.LoadInt64(&counter.n); n != 8001 {
+		t.Fatalf("after Close %d messages written, want 8001", n)
+	}
+}
+
+func TestSetWorkersAfterAsync(t *testing.T) {
+	log := NewLogger(10)
+	counter := &countAppender{}
+	log.AddAppender("count", counter)
+	log.Async()
+	log.SetWorkers(4)
+	done := make(chan struct{})
+	go func() {
+		log.Info("late")
+		log.Flush()
+		log.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Flush or Close hangs after a late SetWorkers")
+	}
+	if n := atomic.LoadInt64(&counter.n); n != 1 {
+		t.Fatalf("%d messages written, want 1", n)
+	}
+}
+
+func TestSetMaxMessageLen(t *testing.T) {
+	log := NewLogger(10)
+	r := addRecorder(log, "record")
+	log.SetMaxMessageLen(10)
+	log.Info("%s", strings.Repeat("x", 2<<20))
+	log.Info("short")
+	log.Info("123456789é after")
+	log.SetMaxMessageLen(0)
+	log.Info("%s", strings.Repeat("y", 20))
+	want := []string{
+		"[I] xxxxxxxxxx…(truncated)",
+		"[I] short",
+		"[I] 123456789…(truncated)",
+		"[I] " + strings.Repeat("y", 20),
+	}
+	if got := r.Lines(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got %q\nwant %q", got, want)
+	}
+}
+
+type destroyAppender struct {
+	*Recorder
+	destroyed chan struct{}
+}
+
+func (d *destroyAppender) Destroy() { close(d.destroyed) }
+
+func TestAsyncContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	log := NewLogger(100)
+	out := &destroyAppender{&Recorder{}, make(chan struct{})}
+	log.AddAppender("record", out)
+	log.AsyncContext(ctx)
+	for i := 0; i < 50; i++ {
+		log.Info("msg %d", i)
+	}
+	cancel()
+	select {
+	case <-out.destroyed:
+	case <-time.After(time.Second):
+		t.Fatal("worker not stopped after cancel")
+	}
+	if n := len(out.Lines()); n != 50 {
+		t.Fatalf("%d messages written before shutdown, want 50", n)
+	}
+	log.Close()
+
+	manual := NewLogger(10).AsyncContext(context.Background())
+	manual.Close()
+	manual.Close()
+}
+
+func TestBackpressureCallback(t *testing.T) {
+	log := NewLogger(10)
+	slow := &blockingAppender{release: make(chan struct{})}
+	log.AddAppender("slow", slow)
+	type report struct{ load, cap int }
+	reports := make(chan report, 10)
+	log.SetBackpressureCallback(0.5, func(load int, cap int) { reports <- report{load, cap} })
+	log.Async()
+	for i := 0; i < 9; i++ {
+		log.Info("msg %d", i)
+	}
+	select {
+	case r := <-reports:
+		if r.cap != 10 || r.load < 5 || r.load > 10 {
+			t.Fatalf("callback got load %d cap %d", r.load, r.cap)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("backpressure callback not called")
+	}
+	close(slow.release)
+	log.Close()
+	if len(reports) != 0 {
+		t.Fatalf("callback not rate limited, %d more calls", len(reports))
+	}
+}
+
+func TestFlushStress(t *testing.T) {
+	log := NewLogger(16)
+	r := addRecorder(log, "record")
+	log.SetWorkers(2)
+	log.Async()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				msg := "g" + strconv.Itoa(g) + "-" + strconv.Itoa(i)
+				log.Info("%s", msg)
+				if i%20 != 0 {
+					continue
+				}
+				log.Flush()
+				found := false
+				for _, line := range r.Lines() {
+					if line == "[I] "+msg {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("%s logged before Flush but not written", msg)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	log.Close()
+	if n := len(r.Lines()); n != 800 {
+		t.Fatalf("%d messages written, want 800", n)
+	}
+}
+
+func TestAsyncIdempotent(t *testing.T) {
+	before := runtime.NumGoroutine()
+	log := NewLogger(10)
+	r := addRecorder(log, "record")
+	if log.IsAsync() {
+		t.Fatal("new logger should be sync")
+	}
+	log.Async()
+	started := runtime.NumGoroutine()
+	log.Async()
+	if !log.IsAsync() {
+		t.Fatal("IsAsync false after Async")
+	}
+	if n := runtime.NumGoroutine(); n > started {
+		t.Fatalf("goroutines before %d, after Async %d, after second Async %d", before, started, n)
+	}
+	log.Info("once")
+	log.Close()
+	if got := r.Lines(); len(got) != 1 {
+		t.Fatalf("got %q, want one message", got)
+	}
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("worker leaked, %d goroutines, %d before", n, before)
+	}
+}
+
+func TestAsyncConcurrent(t *testing.T) {
+	log := NewLogger(10)
+	r := addRecorder(log, "record")
+	before := runtime.NumGoroutine()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if log.Async() != log {
+				t.Error("Async should return the logger")
+			}
+		}()
+	}
+	wg.Wait()
+	if n := runtime.NumGoroutine(); n > before+1 {
+		t.Fatalf("%d goroutines after concurrent Async, %d before: more than one worker", n, before)
+	}
+	for i := 0; i < 200; i++ {
+		log.Info("msg %d", i)
+	}
+	log.Close()
+	lines := r.Lines()
+	if len(lines) != 200 {
+		t.Fatalf("%d messages written, want 200", len(lines))
+	}
+	for i, line := range lines {
+		if want := "[I] msg " + strconv.Itoa(i); line != want {
+			t.Fatalf("message %d is %q, a single worker keeps the order", i, line)
+		}
+	}
+}
+
+type failingAppender struct {
+	Recorder
+}
+
+func (f *failingAppender) WriteMsg(when time.Time, msg string, level int) error {
+	return errors.New("sink down")
+}
+
+func TestSetErrorHandler(t *testing.T) {
+	log := NewLogger(10)
+	log.AddAppender("broken", &failingAppender{})
+	ok := addRecorder(log, "ok")
+	var names []string
+	var errs []error
+	log.SetErrorHandler(func(appenderName string, err error) {
+		names = append(names, appenderName)
+		errs = append(errs, err)
+	})
+	log.Info("to every sink")
+	if len(names) != 1 || names[0] != "broken" || errs[0].Error() != "sink down" {
+		t.Fatalf("handler got %v %v", names, errs)
+	}
+	if len(ok.Lines()) != 1 {
+		t.Fatal("a failing appender must not stop the others")
+	}
+	log.SetErrorHandler(func(string, error) {})
+	log.Info("silenced")
+	if len(names) != 1 {
+		t.Fatal("replaced handler still called")
+	}
+}
+
+func TestSetVersionTag(t *testing.T) {
+	log := NewLogger(10)
+	rec := addRecorder(log, "rec")
+	log.SetVersionTag("1.4.2")
+	log.With(Field{"k", 1}).Info("tagged")
+	log.SetVersionTag("")
+	log.Info("cleared")
+	want := []string{"[I] tagged k=1 {v=1.4.2}", "[I] cleared"}
+	if got := rec.Lines(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	e := &Entry{Level: LevelInfo, Msg: "m", Version: "1.4.2", Fields: []Field{{"version", "user"}}}
+	if got := string((&JSONFormatter{}).Format(e)); !strings.Contains(got, `"version":"1.4.2","fields.version":"user"`) {
+		t.Fatalf("json got %s", got)
+	}
+}
+
+func TestSetUTC(t *testing.T) {
+	dir := t.TempDir()
+	when := time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("UTC+5", 5*3600))
+	render := func(utc bool) (string, string) {
+		text, json := filepath.Join(dir, "text.log"), filepath.Join(dir, "json.log")
+		os.Remove(text)
+		os.Remove(json)
+		log := NewLogger(10)
+		log.SetUTC(utc)
+		log.SetAppender("file", `{"filename":"`+text+`"}`)
+		log.SetAppender("file", `{"filename":"`+json+`","format":"json"}`)
+		log.InfoAt(when, "instant")
+		log.Close()
+		t1, _ := os.ReadFile(text)
+		t2, _ := os.ReadFile(json)
+		return string(t1), string(t2)
+	}
+	text, json := render(false)
+	if !strings.HasPrefix(text, "2020-01-02 03:04:05") || !strings.Contains(json, `"time":"2020-01-02T03:04:05+05:00"`) {
+		t.Fatalf("local rendering got %q %q", text, json)
+	}
+	text, json = render(true)
+	if !strings.HasPrefix(text, "2020-01-01 22:04:05") || !strings.Contains(json, `"time":"2020-01-01T22:04:05Z"`) {
+		t.Fatalf("utc rendering got %q %q", text, json)
+	}
+}
+
+func TestPendingMessages(t *testing.T) {
+	slow := &blockingAppender{release: make(chan struct{})}
+	log := NewLogger(10)
+	log.AddAppender("slow", slow)
+	log.Async()
+	log.Info("taken")
+	deadline := time.Now().Add(time.Second)
+	for log.PendingCount() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 5; i++ {
+		log.Info("queued %d", i)
+	}
+	if n := log.PendingCount(); n != 5 {
+		t.Fatalf("PendingCount %d, want 5", n)
+	}
+	pending := log.DrainPending()
+	if len(pending) != 5 || !strings.HasSuffix(pending[4], "[I] queued 4") {
+		t.Fatalf("DrainPending got %q", pending)
+	}
+	if n := log.PendingCount(); n != 0 {
+		t.Fatalf("PendingCount %d after DrainPending", n)
+	}
+	close(slow.release)
+	log.Close()
+	if lines := slow.Lines(); len(lines) != 1 {
+		t.Fatalf("drained messages were written: %q", lines)
+	}
+}
+
+func TestSetFallback(t *testing.T) {
+	log := NewLogger(10)
+	log.SetErrorHandler(func(string, error) {})
+	primary := &flakyAppender{failures: 1}
+	fallback := &Recorder{}
+	log.AddAppender("primary", primary)
+	if err := log.SetFallback("primary", fallback); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("failed over")
+	log.Info("written")
+	if lines := fallback.Lines(); len(lines) != 1 || !strings.HasSuffix(lines[0], "failed over") {
+		t.Fatalf("fallback got %q", lines)
+	}
+	if lines := primary.Lines(); len(lines) != 1 || !strings.HasSuffix(lines[0], "written") {
+		t.Fatalf("primary got %q", lines)
+	}
+	if err := log.SetFallback("missing", fallback); err == nil {
+		t.Fatal("SetFallback of a missing appender should fail")
+	}
+}
+
+type destroyCounter struct {
+	Recorder
+	destroys int
+}
+
+func (d *destroyCounter) Destroy() {
+	d.destroys++
+}
+
+func TestSharedFallbackDestroyedOnce(t *testing.T) {
+	log := NewLogger(10)
+	fallback := &destroyCounter{}
+	addRecorder(log, "a")
+	addRecorder(log, "b")
+	for _, name := range []string{"a", "b"} {
+		if err := log.SetFallback(name, fallback); err != nil {
+			t.Fatal(err)
+		}
+	}
+	log.RemoveAppender("a")
+	if fallback.destroys != 0 {
+		t.Fatal("fallback destroyed while b still writes to it")
+	}
+	log.Close()
+	if fallback.destroys != 1 {
+		t.Fatalf("shared fallback destroyed %d times", fallback.destroys)
+	}
+}
+
+func TestAppendersSeeSameOrder(t *testing.T) {
+	log := NewLogger(100)
+	a, b := addRecorder(log, "a"), addRecorder(log, "b")
+	log.Async()
+	var wg sync.WaitGroup
+	var late *Recorder
+	for p := 0; p < 4; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				log.Info("producer %d message %d", p, i)
+				if p == 0 && i == 100 {
+					late = &Recorder{}
+					log.AddAppender("late", late)
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+	log.Close()
+	first, second, third := a.Lines(), b.Lines(), late.Lines()
+	if len(first) != 800 || strings.Join(first, "\n") != strings.Join(second, "\n") {
+		t.Fatalf("appenders saw %d and %d messages or a different order", len(first), len(second))
+	}
+	if len(third) == 0 || strings.Join(third, "\n") != strings.Join(first[len(first)-len(third):], "\n") {
+		t.Fatalf("the appender added later did not see a suffix of the same order: %d messages", len(third))
+	}
+}
+
+func TestReset(t *testing.T) {
+	var calls []string
+	log := NewLogger(10)
+	old := &Recorder{}
+	log.AddAppender("old", old)
+	log.AddAppender("order", &orderAppender{"order", &calls})
+	log.SetLevel(LevelError)
+	log.EnableFuncCallDepath(true)
+	log.Async()
+	log.Error("before")
+	log.Reset()
+	if strings.Join(calls, ",") != "flush order,destroy order" {
+		t.Fatalf("Reset calls %q", calls)
+	}
+	if log.Level() != LevelDebug || log.enableFuncCallDepth {
+		t.Fatal("Reset should restore the default level and caller settings")
+	}
+	fresh := &Recorder{}
+	log.AddAppender("fresh", fresh)
+	log.Debug("after")
+	log.Close()
+	if lines := old.Lines(); len(lines) != 1 || !strings.HasSuffix(lines[0], "before") {
+		t.Fatalf("old appender got %q", lines)
+	}
+	if lines := fresh.Lines(); len(lines) != 1 || lines[0] != "[D] after" {
+		t.Fatalf("new appender got %q", lines)
+	}
+	if !log.IsAsync() {
+		t.Fatal("Reset should keep the logger async")
+	}
+}
@@ -0,0 +1,104 @@
+package logg
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+//LogfmtFormatter render entries as `time=... level=info msg="a b" key=value`,
+//grouped keys are joined with dots. Values are quoted when empty or holding
+//spaces, quotes, '=' or control characters.
+type LogfmtFormatter struct {
+	fieldFormatter
+}
+
+//SetFieldKeys rename the time, level and msg keys like JSONFormatter.SetFieldKeys
+func (l *LogfmtFormatter) SetFieldKeys(time, level, msg string) error {
+	return l.setFieldKeys(time, level, msg)
+}
+
+//Format Formatter interface
+func (l *LogfmtFormatter) Format(e *Entry) []byte {
+	keys := l.format.orDefault()
+	var buf bytes.Buffer
+	buf.WriteString(keys.time)
+	buf.WriteByte('=')
+	buf.WriteString(e.When.Format(time.RFC3339Nano))
+	buf.WriteByte(' ')
+	buf.WriteString(keys.level)
+	buf.WriteByte('=')
+	buf.WriteString(levelNames[e.Level])
+	buf.WriteByte(' ')
+	buf.WriteString(keys.msg)
+	buf.WriteByte('=')
+	writeLogfmtValue(&buf, e.Msg)
+	if len(e.Caller) > 0 {
+		buf.WriteString(" caller=")
+		writeLogfmtValue(&buf, e.Caller)
+	}
+	if len(e.Func) > 0 {
+		buf.WriteString(" func=")
+		writeLogfmtValue(&buf, e.Func)
+	}
+	if len(e.Version) > 0 {
+		buf.WriteString(" version=")
+		writeLogfmtValue(&buf, e.Version)
+	}
+	if e.Seq > 0 {
+		buf.WriteString(" seq=")
+		buf.WriteString(strconv.FormatUint(e.Seq, 10))
+	}
+	writeLogfmtObject(&buf, "", userFields(e.Fields, keys))
+	return buf.Bytes()
+}
+
+func writeLogfmtObject(buf *bytes.Buffer, prefix string, o *fieldObject) {
+	for _, key := range o.keys {
+		name := key
+		if len(prefix) > 0 {
+			name = prefix + "." + key
+		}
+		if sub, ok := o.values[key].(*fieldObject); ok {
+			writeLogfmtObject(buf, name, sub)
+			continue
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(name)
+		buf.WriteByte('=')
+		switch v := o.values[key].(type) {
+		case nil:
+			buf.WriteString("null")
+		case string:
+			writeLogfmtValue(buf, v)
+		case error:
+			writeLogfmtValue(buf, v.Error())
+		default:
+			writeLogfmtValue(buf, fmt.Sprint(v))
+		}
+	}
+}
+
+func writeLogfmtValue(buf *bytes.Buffer, v string) {
+	if logfmtNeedsQuote(v) {
+		buf.WriteString(strconv.Quote(v))
+	} else {
+		buf.WriteString(v)
+	}
+}
+
+func logfmtNeedsQuote(v string) bool {
+	if len(v) == 0 {
+		return true
+	}
+	return strings.IndexFunc(v, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == unicode.ReplacementChar || !unicode.IsPrint(r)
+	}) >= 0
+}
+
+func init() {
+	RegisterFormatter("logfmt", func() Formatter { return &LogfmtFormatter{} })
+}
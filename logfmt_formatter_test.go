@@ -0,0 +1,78 @@
+package logg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func formatLogfmt(msg string, fields ...Field) string {
+	when := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	e := &Entry{When: when, Level: LevelInfo, Msg: msg, Fields: fields}
+	return string((&LogfmtFormatter{}).Format(e))
+}
+
+func TestLogfmtFormatterQuoting(t *testing.T) {
+	got := formatLogfmt("user logged in", Field{"user", "bob"}, Field{"path", "/a b"}, Field{"expr", "a=b"}, Field{"n", 3})
+	want := `time=2020-01-02T03:04:05Z level=info msg="user logged in" user=bob path="/a b" expr="a=b" n=3`
+	if got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestLogfmtFormatterEmbeddedQuotes(t *testing.T) {
+	got := formatLogfmt(`say "hi"`, Field{"q", `"quoted"`}, Field{"nl", "a\nb"}, Field{"err", errors.New("bad thing")})
+	want := `time=2020-01-02T03:04:05Z level=info msg="say \"hi\"" q="\"quoted\"" nl="a\nb" err="bad thing"`
+	if got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestLogfmtFormatterEmptyValues(t *testing.T) {
+	got := formatLogfmt("", Field{"empty", ""}, Field{"nil", nil}, Group("req", Field{"id", 7}))
+	want := `time=2020-01-02T03:04:05Z level=info msg="" empty="" nil=null req.id=7`
+	if got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestFieldOrderIsStable(t *testing.T) {
+	render := func() (string, string) {
+		meta := map[string]interface{}{}
+		for _, key := range []string{"zeta", "alpha", "mid", "beta", "omega", "gamma"} {
+			meta[key] = len(key)
+		}
+		fields := []Field{{"z", 1}, {"a", 2}, {"meta", meta}, Group("g", Field{"y", 3}, Field{"b", 4}), {"m", 5}}
+		e := &Entry{Level: LevelInfo, Msg: "order", Fields: fields}
+		return e.Text(), formatLogfmt("order", fields...)
+	}
+	wantText := "[I] order z=1 a=2 meta.alpha=5 meta.beta=4 meta.gamma=5 meta.mid=3 meta.omega=5 meta.zeta=4 g.y=3 g.b=4 m=5"
+	wantLogfmt := "time=2020-01-02T03:04:05Z level=info msg=order z=1 a=2 meta.alpha=5 meta.beta=4 meta.gamma=5 " +
+		"meta.mid=3 meta.omega=5 meta.zeta=4 g.y=3 g.b=4 m=5"
+	for i := 0; i < 50; i++ {
+		text, logfmt := render()
+		if text != wantText || logfmt != wantLogfmt {
+			t.Fatalf("run %d got\n%s\n%s", i, text, logfmt)
+		}
+	}
+}
+
+func TestLogfmtFileAppender(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "logfmt.log")
+	log := NewLogger(10)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","format":"logfmt"}`); err != nil {
+		t.Fatal(err)
+	}
+	log.With(Field{"k", "v"}).Info("hello logfmt")
+	log.Close()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "time=") || !strings.HasSuffix(string(data), ` level=info msg="hello logfmt" k=v`+"\n") {
+		t.Fatalf("unexpected logfmt line %q", data)
+	}
+}
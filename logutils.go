@@ -1,24 +1,145 @@
 package logg
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"io"
+	"strconv"
 	"sync"
 	"time"
 )
 
+//lineWrap the "lineprefix" and "linesuffix" appender options, written
+//around every line, the level label included, and "lineending"
+type lineWrap struct {
+	LinePrefix string `json:"lineprefix"`
+	LineSuffix string `json:"linesuffix"`
+	LineEnding string `json:"lineending"` //lf (default) or crlf
+	eol        string
+}
+
+//init check LineEnding and resolve it
+func (w *lineWrap) init() error {
+	switch w.LineEnding {
+	case "", "lf":
+		w.eol = "\n"
+	case "crlf":
+		w.eol = "\r\n"
+	default:
+		return errors.New("logg: unknow lineending " + w.LineEnding)
+	}
+	return nil
+}
+
+//end terminate a line
+func (w *lineWrap) end(b []byte) []byte {
+	if len(w.eol) == 0 {
+		return append(b, '\n')
+	}
+	return append(b, w.eol...)
+}
+
 type logWriter struct {
 	sync.Mutex
-	writer io.Writer
+	writer    io.Writer
+	layout    string
+	noTime    bool  //no timestamp before the text
+	timePaint brush //colors the timestamp when not nil
+	wrap      lineWrap
+	framing   string //"", sddaemon or length, see consoleWriter
+	buf       []byte
+}
+
+//start begin a line in buf, room is left for the length prefix
+func (lg *logWriter) start(level int) {
+	lg.buf = lg.buf[:0]
+	switch lg.framing {
+	case "sddaemon":
+		lg.buf = append(lg.buf, '<')
+		lg.buf = strconv.AppendInt(lg.buf, int64(syslogSeverities[level]), 10)
+		lg.buf = append(lg.buf, '>')
+	case "length":
+		lg.buf = append(lg.buf, 0, 0, 0, 0)
+	}
+	lg.buf = append(lg.buf, lg.wrap.LinePrefix...)
+}
+
+//finish end the line of buf and write it, a length frame holds the line
+//without line ending, must hold the lock
+func (lg *logWriter) finish() {
+	lg.buf = append(lg.buf, lg.wrap.LineSuffix...)
+	if lg.framing == "length" {
+		binary.BigEndian.PutUint32(lg.buf, uint32(len(lg.buf)-4))
+	} else {
+		lg.buf = lg.wrap.end(lg.buf)
+	}
+	lg.writer.Write(lg.buf)
 }
 
 func newLogWriter(wr io.Writer) *logWriter {
-	return &logWriter{writer: wr}
+	return &logWriter{writer: wr, layout: timeLayout}
 }
 
-func (lg *logWriter) println(when time.Time, msg string) {
+func (lg *logWriter) println(when time.Time, msg string, level int) {
 	lg.Lock()
-	str := when.Format("2006-01-02 15:04:05")
-	str = str + msg + "\n"
-	lg.writer.Write([]byte(str))
+	lg.start(level)
+	switch {
+	case lg.noTime:
+	case lg.timePaint != nil:
+		lg.buf = append(lg.buf, lg.timePaint(when.Format(lg.layout))...)
+	default:
+		lg.buf = when.AppendFormat(lg.buf, lg.layout)
+	}
+	lg.buf = append(lg.buf, msg...)
+	lg.finish()
 	lg.Unlock()
 }
+
+//printEntry write the text output of e, paint colors the part after the
+//timestamp when not nil
+func (lg *logWriter) printEntry(e *Entry, paint brush) {
+	lg.Lock()
+	lg.start(e.Level)
+	switch {
+	case lg.noTime:
+	case lg.timePaint != nil:
+		lg.buf = append(lg.buf, lg.timePaint(string(e.appendStamp(nil, lg.layout)))...)
+	default:
+		lg.buf = e.appendStamp(lg.buf, lg.layout)
+	}
+	if paint == nil {
+		lg.buf = e.appendText(lg.buf)
+	} else {
+		lg.buf = append(lg.buf, paint(e.Text())...)
+	}
+	lg.finish()
+	lg.Unlock()
+}
+
+func (lg *logWriter) writeln(b []byte, level int) {
+	lg.Lock()
+	lg.start(level)
+	lg.buf = append(lg.buf, b...)
+	lg.finish()
+	lg.Unlock()
+}
+
+//escapeNewlines replace \n and \r by `\n` and `\r`, b is returned when it has none
+func escapeNewlines(b []byte) []byte {
+	if bytes.IndexAny(b, "\r\n") < 0 {
+		return b
+	}
+	escaped := make([]byte, 0, len(b)+8)
+	for _, c := range b {
+		switch c {
+		case '\n':
+			escaped = append(escaped, '\\', 'n')
+		case '\r':
+			escaped = append(escaped, '\\', 'r')
+		default:
+			escaped = append(escaped, c)
+		}
+	}
+	return escaped
+}
@@ -0,0 +1,31 @@
+//go:build otel
+// +build otel
+
+//Package otellogg adds OpenTelemetry trace correlation to logg,
+//build with `-tags otel` to pull in the otel dependency
+package otellogg
+
+import (
+	"context"
+
+	"github.com/colefan/logg"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//TraceFields return trace_id and span_id of the span active in ctx,
+//nothing when ctx carries no valid span context
+func TraceFields(ctx context.Context) []logg.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []logg.Field{
+		{Key: "trace_id", Value: sc.TraceID().String()},
+		{Key: "span_id", Value: sc.SpanID().String()},
+	}
+}
+
+//Enable add TraceFields to log's context extractors
+func Enable(log *logg.BaseLogger) {
+	log.AddContextExtractor(TraceFields)
+}
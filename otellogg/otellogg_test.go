@@ -0,0 +1,32 @@
+//go:build otel
+// +build otel
+
+package otellogg
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceFields(t *testing.T) {
+	tid, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	sid, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: tid, SpanID: sid, TraceFlags: trace.FlagsSampled})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	fields := TraceFields(ctx)
+	if len(fields) != 2 {
+		t.Fatalf("expected trace_id and span_id, got %v", fields)
+	}
+	if fields[0].Key != "trace_id" || fields[0].Value != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("bad trace_id field %v", fields[0])
+	}
+	if fields[1].Key != "span_id" || fields[1].Value != "00f067aa0ba902b7" {
+		t.Fatalf("bad span_id field %v", fields[1])
+	}
+	if TraceFields(context.Background()) != nil {
+		t.Fatal("context without span must not add fields")
+	}
+}
@@ -0,0 +1,28 @@
+package logg
+
+import "time"
+
+//FlushOnPanicTimeout how long FlushOnPanic waits for the appenders
+var FlushOnPanicTimeout = 3 * time.Second
+
+//FlushOnPanic to be deferred in main or a goroutine: on a panic it logs the
+//panic value at fatal level, flushes the logger for at most
+//FlushOnPanicTimeout then panics again with the same value
+func (log *BaseLogger) FlushOnPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	//not writeMsg, the panic goes on even with EnableFatalExit
+	log.output(0, time.Now(), LevelFatal, sprintf("panic: %v", r), nil)
+	flushed := make(chan struct{})
+	go func() {
+		log.Flush()
+		close(flushed)
+	}()
+	select {
+	case <-flushed:
+	case <-time.After(FlushOnPanicTimeout):
+	}
+	panic(r)
+}
@@ -0,0 +1,44 @@
+package logg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFlushOnPanic(t *testing.T) {
+	log := NewLogger(1000)
+	defer log.Close()
+	r := addRecorder(log, "record")
+	log.Async()
+	crash := func() {
+		defer log.FlushOnPanic()
+		for i := 0; i < 500; i++ {
+			log.Info("buffered %d", i)
+		}
+		panic("boom")
+	}
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Fatalf("panic value %v, want boom", v)
+			}
+		}()
+		crash()
+	}()
+	lines := r.Lines()
+	if len(lines) != 501 || !strings.HasSuffix(lines[500], "panic: boom") {
+		t.Fatalf("got %d lines, last %q", len(lines), lines[len(lines)-1])
+	}
+}
+
+func TestFlushOnPanicWithoutPanic(t *testing.T) {
+	log := NewLogger(10)
+	r := addRecorder(log, "record")
+	func() {
+		defer log.FlushOnPanic()
+		log.Info("fine")
+	}()
+	if lines := r.Lines(); len(lines) != 1 {
+		t.Fatalf("got %q", lines)
+	}
+}
@@ -0,0 +1,57 @@
+package logg
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+//levelPipe split what is written to it into lines logged at level
+type levelPipe struct {
+	sync.Mutex
+	log   *BaseLogger
+	level int
+	buf   []byte
+}
+
+//LevelPipe return a writer logging every line written to it at level, like
+//the output of a subprocess. Partial lines are buffered until their newline
+//arrives, Close logs the remainder. A level out of Fatal..Debug logs nothing
+func (log *BaseLogger) LevelPipe(level int) io.WriteCloser {
+	return &levelPipe{log: log, level: level}
+}
+
+func (p *levelPipe) Write(b []byte) (int, error) {
+	p.Lock()
+	defer p.Unlock()
+	p.buf = append(p.buf, b...)
+	start := 0
+	for {
+		i := bytes.IndexByte(p.buf[start:], '\n')
+		if i < 0 {
+			break
+		}
+		p.emit(p.buf[start : start+i])
+		start += i + 1
+	}
+	p.buf = append(p.buf[:0], p.buf[start:]...)
+	return len(b), nil
+}
+
+//Close log the last line if it has no newline
+func (p *levelPipe) Close() error {
+	p.Lock()
+	defer p.Unlock()
+	if len(p.buf) > 0 {
+		p.emit(p.buf)
+		p.buf = nil
+	}
+	return nil
+}
+
+func (p *levelPipe) emit(line []byte) {
+	if p.level < LevelFatal || p.level > LevelDebug || p.log.skipped(p.level) {
+		return
+	}
+	p.log.writeMsg(p.level, string(bytes.TrimSuffix(line, []byte("\r"))), nil)
+}
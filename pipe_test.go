@@ -0,0 +1,40 @@
+package logg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLevelPipe(t *testing.T) {
+	log := NewLogger(10)
+	r := addRecorder(log, "record")
+	pipe := log.LevelPipe(LevelWarn)
+	for _, chunk := range []string{"par", "tial\nsecond\r\nthi", "rd\n\nfour", "th"} {
+		if n, err := pipe.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	want := []string{"[W] partial", "[W] second", "[W] third", "[W] "}
+	if got := r.Lines(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("before Close got %q, want %q", got, want)
+	}
+	pipe.Close()
+	want = append(want, "[W] fourth")
+	if got := r.Lines(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("after Close got %q, want %q", got, want)
+	}
+}
+
+func TestLevelPipeOutOfRange(t *testing.T) {
+	log := NewLogger(10)
+	log.SetLevel(LevelDebug + 1)
+	r := addRecorder(log, "record")
+	for _, level := range []int{LevelFatal - 1, LevelDebug + 1} {
+		pipe := log.LevelPipe(level)
+		pipe.Write([]byte("out of range\n"))
+		pipe.Close()
+	}
+	if got := r.Lines(); len(got) != 0 {
+		t.Fatalf("out of range level logged %q", got)
+	}
+}
@@ -0,0 +1,37 @@
+package logg
+
+import (
+	"encoding/json"
+	"time"
+)
+
+//NewDevelopment return a logger for local work: colored console output of
+//every level with the caller file and line, written synchronously
+func NewDevelopment() *BaseLogger {
+	log := NewLogger(defautChannelBuffer)
+	log.SetLevel(LevelDebug)
+	log.EnableFuncCallDepath(true)
+	if err := log.SetAppender("console", `{"level":4,"color":true}`); err != nil {
+		panic("logg: NewDevelopment " + err.Error())
+	}
+	return log
+}
+
+//NewProduction return a logger writing Info and more severe messages as JSON
+//lines to filename, asynchronously, without caller and sampled per second:
+//the first 100 messages of a level and text, then one in 100
+func NewProduction(filename string) (*BaseLogger, error) {
+	config, _ := json.Marshal(map[string]interface{}{
+		"filename": filename,
+		"level":    LevelInfo,
+		"format":   "json",
+	})
+	log := NewLogger(defautChannelBuffer)
+	log.SetLevel(LevelInfo)
+	log.EnableFuncCallDepath(false)
+	if err := log.SetAppender("file", string(config)); err != nil {
+		return nil, err
+	}
+	log.SetSampling(time.Second, 100, 100)
+	return log.Async(), nil
+}
@@ -0,0 +1,51 @@
+package logg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewDevelopment(t *testing.T) {
+	log := NewDevelopment()
+	defer log.Close()
+	if log.Level() != LevelDebug || !log.enableFuncCallDepth || log.IsAsync() {
+		t.Fatalf("level %d, caller %v, async %v", log.Level(), log.enableFuncCallDepth, log.IsAsync())
+	}
+	if len(log.appenders) != 1 || log.appenders[0].name != "console" {
+		t.Fatalf("expected a console appender, got %d appenders", len(log.appenders))
+	}
+	if console := log.appenders[0].Appender.(*consoleWriter); console.Level != LevelDebug {
+		t.Fatalf("console level %d", console.Level)
+	}
+}
+
+func TestNewProduction(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "prod.log")
+	log, err := NewProduction(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.Level() != LevelInfo || log.enableFuncCallDepth || !log.IsAsync() || log.sampler.Load().(*sampler) == nil {
+		t.Fatalf("level %d, caller %v, async %v", log.Level(), log.enableFuncCallDepth, log.IsAsync())
+	}
+	if len(log.appenders) != 1 || log.appenders[0].name != "file" {
+		t.Fatalf("expected a file appender, got %d appenders", len(log.appenders))
+	}
+	for i := 0; i < 300; i++ {
+		log.Info("hot path")
+	}
+	log.Debug("not written")
+	log.Close()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), `"msg":"hot path"`); n != 102 {
+		t.Fatalf("sampling kept %d of 300 messages, want 100 + 2", n)
+	}
+	if !strings.HasPrefix(string(data), `{"time":`) || strings.Contains(string(data), "not written") {
+		t.Fatalf("unexpected output %q", data[:80])
+	}
+}
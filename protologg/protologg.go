@@ -0,0 +1,351 @@
+//Package protologg writes messages as length-prefixed protobuf frames, the
+//schema is logg.proto. The encoding is done by hand so no protobuf runtime is
+//needed, any protobuf library can decode the frames:
+//
+//	import _ "github.com/colefan/logg/protologg"
+//
+//	log.SetAppender("protofile", `{"filename":"app.binlog","maxsize":67108864}`)
+package protologg
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/colefan/logg"
+)
+
+//MaxFrameSize the largest payload ReadFrame accepts
+const MaxFrameSize = 16 << 20
+
+//Field a flattened user field of a Record
+type Field struct {
+	Key   string
+	Value string
+}
+
+//Record the decoded form of the protobuf Record message
+type Record struct {
+	When   time.Time
+	Level  int
+	Msg    string
+	Caller string
+	Fields []Field
+}
+
+//ProtoFormatter render an entry as an encoded Record, without the frame
+type ProtoFormatter struct{}
+
+//Format Formatter interface
+func (p *ProtoFormatter) Format(e *logg.Entry) []byte {
+	return Marshal(e)
+}
+
+//Marshal encode e as a protobuf Record
+func Marshal(e *logg.Entry) []byte {
+	var buf []byte
+	if !e.When.IsZero() {
+		buf = appendVarint(buf, 1, uint64(e.When.UnixNano()))
+	}
+	if e.Level != 0 {
+		buf = appendVarint(buf, 2, uint64(e.Level))
+	}
+	buf = appendString(buf, 3, e.Msg)
+	buf = appendString(buf, 4, e.Caller)
+	for _, f := range flatten(nil, "", e.Fields) {
+		var sub []byte
+		sub = appendString(sub, 1, f.Key)
+		sub = appendString(sub, 2, f.Value)
+		buf = appendBytes(buf, 5, sub)
+	}
+	return buf
+}
+
+func flatten(dst []Field, prefix string, fields []logg.Field) []Field {
+	for _, f := range fields {
+		key := f.Key
+		if len(prefix) > 0 && len(key) > 0 {
+			key = prefix + "." + key
+		} else if len(key) == 0 {
+			key = prefix
+		}
+		if group, ok := f.Value.([]logg.Field); ok {
+			dst = flatten(dst, key, group)
+			continue
+		}
+		dst = append(dst, Field{Key: key, Value: fmt.Sprint(f.Value)})
+	}
+	return dst
+}
+
+func appendVarint(buf []byte, num int, v uint64) []byte {
+	buf = binary.AppendUvarint(buf, uint64(num)<<3)
+	return binary.AppendUvarint(buf, v)
+}
+
+func appendBytes(buf []byte, num int, b []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(num)<<3|2)
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func appendString(buf []byte, num int, s string) []byte {
+	if len(s) == 0 {
+		return buf
+	}
+	return appendBytes(buf, num, []byte(s))
+}
+
+var errMalformed = errors.New("protologg: malformed record")
+
+//field read the next field of a message, value is the varint or the
+//length-delimited bytes
+func field(b []byte) (num int, wire int, v uint64, data []byte, rest []byte, err error) {
+	tag, n := binary.Uvarint(b)
+	if n <= 0 {
+		return 0, 0, 0, nil, nil, errMalformed
+	}
+	b = b[n:]
+	num, wire = int(tag>>3), int(tag&7)
+	switch wire {
+	case 0:
+		v, n = binary.Uvarint(b)
+		if n <= 0 {
+			return 0, 0, 0, nil, nil, errMalformed
+		}
+		return num, wire, v, nil, b[n:], nil
+	case 1:
+		if len(b) < 8 {
+			return 0, 0, 0, nil, nil, errMalformed
+		}
+		return num, wire, binary.LittleEndian.Uint64(b), nil, b[8:], nil
+	case 2:
+		l, n := binary.Uvarint(b)
+		if n <= 0 || l > uint64(len(b)-n) {
+			return 0, 0, 0, nil, nil, errMalformed
+		}
+		b = b[n:]
+		return num, wire, 0, b[:l], b[l:], nil
+	case 5:
+		if len(b) < 4 {
+			return 0, 0, 0, nil, nil, errMalformed
+		}
+		return num, wire, uint64(binary.LittleEndian.Uint32(b)), nil, b[4:], nil
+	}
+	return 0, 0, 0, nil, nil, errMalformed
+}
+
+//Unmarshal decode a protobuf Record, unknown fields are skipped
+func Unmarshal(b []byte) (*Record, error) {
+	r := &Record{}
+	for len(b) > 0 {
+		num, wire, v, data, rest, err := field(b)
+		if err != nil {
+			return nil, err
+		}
+		b = rest
+		switch {
+		case num == 1 && wire == 0:
+			r.When = time.Unix(0, int64(v))
+		case num == 2 && wire == 0:
+			r.Level = int(int32(v))
+		case num == 3 && wire == 2:
+			r.Msg = string(data)
+		case num == 4 && wire == 2:
+			r.Caller = string(data)
+		case num == 5 && wire == 2:
+			f, err := unmarshalField(data)
+			if err != nil {
+				return nil, err
+			}
+			r.Fields = append(r.Fields, f)
+		}
+	}
+	return r, nil
+}
+
+func unmarshalField(b []byte) (Field, error) {
+	var f Field
+	for len(b) > 0 {
+		num, wire, _, data, rest, err := field(b)
+		if err != nil {
+			return f, err
+		}
+		b = rest
+		switch {
+		case num == 1 && wire == 2:
+			f.Key = string(data)
+		case num == 2 && wire == 2:
+			f.Value = string(data)
+		}
+	}
+	return f, nil
+}
+
+//AppendFrame append payload to dst behind its big-endian uint32 length
+func AppendFrame(dst []byte, payload []byte) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(payload)))
+	return append(dst, payload...)
+}
+
+//ReadFrame read and decode the next frame of r, io.EOF at the end of r and
+//io.ErrUnexpectedEOF when r ends inside a frame
+func ReadFrame(r io.Reader) (*Record, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > MaxFrameSize {
+		return nil, errors.New("protologg: frame of " + strconv.FormatUint(uint64(n), 10) + " bytes is too large")
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return Unmarshal(payload)
+}
+
+//protoFileAppender write frames to a file, frames are whole in the file and
+//a rotation happens between two frames
+type protoFileAppender struct {
+	sync.Mutex
+	Filename string `json:"filename"`
+	MaxSize  int64  `json:"maxsize"` //bytes before the file is rotated, 0 never
+	Level    int    `json:"level"`
+	MaxLevel int    `json:"maxlevel"`
+	file     *os.File
+	w        *bufio.Writer
+	size     int64
+	frame    []byte
+}
+
+func newProtoFileAppender() logg.Appender {
+	return &protoFileAppender{Level: logg.LevelDebug}
+}
+
+//Init config like
+//{
+//"filename":"app.binlog",
+//"maxsize":67108864,
+//"level":4,
+//}
+func (p *protoFileAppender) Init(config string) error {
+	if err := json.Unmarshal([]byte(config), p); err != nil {
+		return err
+	}
+	if len(p.Filename) == 0 {
+		return errors.New("protofile appender config must have filename")
+	}
+	return p.open()
+}
+
+func (p *protoFileAppender) open() error {
+	file, err := os.OpenFile(p.Filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0660)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	p.file = file
+	p.w = bufio.NewWriter(file)
+	p.size = info.Size()
+	return nil
+}
+
+//WriteMsg Appender interface, msg is written without time and fields
+func (p *protoFileAppender) WriteMsg(when time.Time, msg string, level int) error {
+	return p.WriteEntry(&logg.Entry{When: when, Level: level, Msg: msg})
+}
+
+//WriteEntry EntryAppender interface
+func (p *protoFileAppender) WriteEntry(e *logg.Entry) error {
+	if e.Level > p.Level || e.Level < p.MaxLevel {
+		return nil
+	}
+	p.Lock()
+	defer p.Unlock()
+	if p.w == nil {
+		return errors.New("protofile appender is closed")
+	}
+	p.frame = AppendFrame(p.frame[:0], Marshal(e))
+	if p.MaxSize > 0 && p.size > 0 && p.size+int64(len(p.frame)) > p.MaxSize {
+		if err := p.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := p.w.Write(p.frame)
+	p.size += int64(n)
+	return err
+}
+
+//rotate move the file to the first free "<filename>.<n>", must hold the lock
+func (p *protoFileAppender) rotate() error {
+	if err := p.w.Flush(); err != nil {
+		return err
+	}
+	p.file.Close()
+	p.w = nil
+	var name string
+	for n := 1; ; n++ {
+		name = p.Filename + "." + strconv.Itoa(n)
+		if _, err := os.Lstat(name); os.IsNotExist(err) {
+			break
+		}
+	}
+	if err := os.Rename(p.Filename, name); err != nil {
+		if openErr := p.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+	return p.open()
+}
+
+//Rotate logg.Rotatable interface
+func (p *protoFileAppender) Rotate() error {
+	p.Lock()
+	defer p.Unlock()
+	if p.w == nil {
+		return errors.New("protofile appender is closed")
+	}
+	return p.rotate()
+}
+
+//Flush Appender interface
+func (p *protoFileAppender) Flush() {
+	p.Lock()
+	defer p.Unlock()
+	if p.w != nil {
+		p.w.Flush()
+		p.file.Sync()
+	}
+}
+
+//Destroy Appender interface
+func (p *protoFileAppender) Destroy() {
+	p.Lock()
+	defer p.Unlock()
+	if p.w != nil {
+		p.w.Flush()
+		p.file.Close()
+		p.w = nil
+	}
+}
+
+func init() {
+	logg.RegisterAppender("protofile", newProtoFileAppender)
+}
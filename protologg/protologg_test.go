@@ -0,0 +1,120 @@
+package protologg
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/colefan/logg"
+)
+
+func readFrames(t *testing.T, name string) []*Record {
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	var records []*Record
+	for {
+		rec, err := ReadFrame(r)
+		if err == io.EOF {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		records = append(records, rec)
+	}
+}
+
+func TestProtoFileAppender(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.binlog")
+	log := logg.NewLogger(10)
+	log.EnableFuncCallDepath(true)
+	if err := log.SetAppender("protofile", `{"filename":"`+filename+`"}`); err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now()
+	log.Warn("line\nbreak")
+	log.WithGroup("req").With(logg.Field{Key: "id", Value: 7}).Info("hello %s", "proto")
+	log.Debug("")
+	log.Close()
+
+	records := readFrames(t, filename)
+	if len(records) != 3 {
+		t.Fatalf("got %d records", len(records))
+	}
+	first := records[0]
+	if first.Level != logg.LevelWarn || first.Msg != "line\nbreak" || first.When.Before(before.Add(-time.Second)) {
+		t.Fatalf("bad first record %+v", first)
+	}
+	if len(first.Caller) == 0 {
+		t.Fatal("caller is missing")
+	}
+	second := records[1]
+	if second.Msg != "hello proto" || len(second.Fields) != 1 || second.Fields[0] != (Field{"req.id", "7"}) {
+		t.Fatalf("bad second record %+v", second)
+	}
+	if records[2].Level != logg.LevelDebug || records[2].Msg != "" {
+		t.Fatalf("bad third record %+v", records[2])
+	}
+}
+
+func TestProtoFileAppenderRotate(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.binlog")
+	log := logg.NewLogger(10)
+	if err := log.SetAppender("protofile", `{"filename":"`+filename+`","maxsize":200}`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 40; i++ {
+		log.Info("message %d", i)
+		if i%7 == 0 {
+			log.Flush()
+		}
+	}
+	log.Close()
+
+	var msgs []string
+	names := []string{}
+	for n := 1; ; n++ {
+		name := filename + "." + strconv.Itoa(n)
+		if _, err := os.Stat(name); err != nil {
+			break
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		t.Fatal("maxsize did not rotate")
+	}
+	for _, name := range append(names, filename) {
+		info, _ := os.Stat(name)
+		if info.Size() > 200 {
+			t.Fatalf("%s has %d bytes", name, info.Size())
+		}
+		for _, rec := range readFrames(t, name) {
+			msgs = append(msgs, rec.Msg)
+		}
+	}
+	if len(msgs) != 40 {
+		t.Fatalf("got %d records", len(msgs))
+	}
+	for i, msg := range msgs {
+		if msg != "message "+strconv.Itoa(i) {
+			t.Fatalf("record %d is %q", i, msg)
+		}
+	}
+}
+
+func TestReadFrameTruncated(t *testing.T) {
+	frame := AppendFrame(nil, Marshal(&logg.Entry{Level: logg.LevelInfo, Msg: "cut"}))
+	if _, err := ReadFrame(bytes.NewReader(frame[:len(frame)-1])); err != io.ErrUnexpectedEOF {
+		t.Fatalf("truncated frame got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package logg
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+//FieldMaxDepth how deep structs and maps in field values are expanded,
+//deeper ones are rendered as depthMarker, which also stops cycles
+var FieldMaxDepth = 5
+
+const depthMarker = "…"
+
+//emptyObject the value of a struct or map expanding to no field, rendered {}
+//so an empty map is told apart from an absent one
+type emptyObject struct{}
+
+func (emptyObject) String() string { return "{}" }
+
+//MarshalJSON json.Marshaler interface
+func (emptyObject) MarshalJSON() ([]byte, error) { return []byte("{}"), nil }
+
+//expandable the struct or map v holds, false for the values rendered as they
+//are, like the ones with their own String, Error or MarshalJSON
+func expandable(v interface{}) (reflect.Value, bool) {
+	switch v.(type) {
+	case nil, string, bool, int, int64, uint64, float64, []Field, time.Time, time.Duration,
+		error, fmt.Stringer, json.Marshaler:
+		return reflect.Value{}, false
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Map && rv.IsNil() {
+		//rendered as it is, null in json
+		return reflect.Value{}, false
+	}
+	return rv, rv.Kind() == reflect.Struct || rv.Kind() == reflect.Map
+}
+
+//expandFields turn a struct, a pointer to one or a map into fields named
+//after the json tags or the sorted map keys, false for the other values
+func expandFields(v interface{}, depth int) ([]Field, bool) {
+	rv, ok := expandable(v)
+	if !ok {
+		return nil, false
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		return structFields(rv, depth), true
+	case reflect.Map:
+		keys := rv.MapKeys()
+		names := make([]string, len(keys))
+		for i, key := range keys {
+			names[i] = fmt.Sprint(key.Interface())
+		}
+		order := make([]int, len(keys))
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(a, b int) bool { return names[order[a]] < names[order[b]] })
+		fields := make([]Field, 0, len(keys))
+		for _, i := range order {
+			fields = append(fields, Field{names[i], expandValue(rv.MapIndex(keys[i]).Interface(), depth+1)})
+		}
+		return fields, true
+	}
+	return nil, false
+}
+
+func structFields(rv reflect.Value, depth int) []Field {
+	rt := rv.Type()
+	fields := make([]Field, 0, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if len(sf.PkgPath) > 0 && !sf.Anonymous {
+			continue
+		}
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := rv.Field(i)
+		if strings.Contains(","+opts+",", ",omitempty,") && fv.IsZero() {
+			continue
+		}
+		if len(name) == 0 && sf.Anonymous {
+			//embedded structs are inlined like encoding/json does, except the
+			//ones of unexported types reflect can not read
+			if fv.CanInterface() {
+				if group, ok := expandFields(fv.Interface(), depth); ok {
+					fields = append(fields, Group("", group...))
+				}
+			}
+			continue
+		}
+		if len(sf.PkgPath) > 0 {
+			continue
+		}
+		if len(name) == 0 {
+			name = sf.Name
+		}
+		fields = append(fields, Field{name, expandValue(fv.Interface(), depth+1)})
+	}
+	return fields
+}
+
+//expandValue the fields of v at depth, v itself when it is not expanded
+func expandValue(v interface{}, depth int) interface{} {
+	if depth >= FieldMaxDepth {
+		if _, ok := expandable(v); ok {
+			return depthMarker
+		}
+		return v
+	}
+	if fields, ok := expandFields(v, depth); ok {
+		if len(fields) == 0 {
+			return emptyObject{}
+		}
+		return fields
+	}
+	return v
+}
@@ -0,0 +1,70 @@
+package logg
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+//retryAppender retry the writes failing on inner
+type retryAppender struct {
+	inner     Appender
+	attempts  int
+	baseDelay time.Duration
+}
+
+//NewRetryAppender return an appender writing to inner up to attempts times,
+//waiting baseDelay, 2*baseDelay, 4*baseDelay... plus up to 50% jitter between
+//them. The last error is returned to the logger's error handler. Retries block
+//the writing goroutine, wrap it in a FanoutAppender to keep the others going.
+//inner must already be initialized
+func NewRetryAppender(inner Appender, attempts int, baseDelay time.Duration) Appender {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return &retryAppender{inner: inner, attempts: attempts, baseDelay: baseDelay}
+}
+
+func (r *retryAppender) Init(config string) error {
+	return nil
+}
+
+func (r *retryAppender) WriteMsg(when time.Time, msg string, level int) error {
+	return r.retry(func() error { return r.inner.WriteMsg(when, msg, level) })
+}
+
+func (r *retryAppender) WriteEntry(e *Entry) error {
+	if ea, ok := r.inner.(EntryAppender); ok {
+		return r.retry(func() error { return ea.WriteEntry(e) })
+	}
+	text := e.Text()
+	return r.retry(func() error { return r.inner.WriteMsg(e.When, text, e.Level) })
+}
+
+func (r *retryAppender) includesGoroutine() bool {
+	return wantsGoroutine(r.inner)
+}
+
+func (r *retryAppender) retry(write func() error) error {
+	delay := r.baseDelay
+	var err error
+	for i := 0; i < r.attempts; i++ {
+		if i > 0 && delay > 0 {
+			time.Sleep(delay + time.Duration(rand.Int63n(int64(delay)/2+1)))
+			delay *= 2
+		}
+		if err = write(); err == nil {
+			return nil
+		}
+	}
+	return errors.New("logg: gave up after " + strconv.Itoa(r.attempts) + " attempts: " + err.Error())
+}
+
+func (r *retryAppender) Flush() {
+	r.inner.Flush()
+}
+
+func (r *retryAppender) Destroy() {
+	r.inner.Destroy()
+}
@@ -0,0 +1,44 @@
+package logg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+//flakyAppender fail the first failures writes
+type flakyAppender struct {
+	Recorder
+	failures int
+	calls    int
+}
+
+func (f *flakyAppender) WriteMsg(when time.Time, msg string, level int) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return errors.New("flaky")
+	}
+	return f.Recorder.WriteMsg(when, msg, level)
+}
+
+func TestRetryAppender(t *testing.T) {
+	log := NewLogger(10)
+	flaky := &flakyAppender{failures: 2}
+	log.AddAppender("retry", NewRetryAppender(flaky, 3, time.Millisecond))
+	var errs []error
+	log.SetErrorHandler(func(name string, err error) { errs = append(errs, err) })
+	log.Info("lands")
+	if lines := flaky.Lines(); len(lines) != 1 || !strings.HasSuffix(lines[0], "lands") || flaky.calls != 3 {
+		t.Fatalf("got %q after %d calls", lines, flaky.calls)
+	}
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+
+	flaky.calls, flaky.failures = 0, 5
+	log.Info("lost")
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "gave up after 3 attempts: flaky") || flaky.calls != 3 {
+		t.Fatalf("got errors %v after %d calls", errs, flaky.calls)
+	}
+}
@@ -0,0 +1,72 @@
+package logg
+
+import (
+	"io"
+	"time"
+)
+
+//RouteDefault the NewRoutingAppender key of the writer taking the levels
+//without their own writer
+const RouteDefault = -1
+
+//routingAppender write each level to its own writer
+type routingAppender struct {
+	routes   map[int]*logWriter
+	fallback *logWriter
+}
+
+//NewRoutingAppender return an appender writing the text output of each level
+//to routes[level], like LevelError to a file and LevelDebug to io.Discard.
+//Levels without writer go to routes[RouteDefault], or are dropped without it.
+//A writer used for several levels is written by one of them at a time
+func NewRoutingAppender(routes map[int]io.Writer) Appender {
+	r := &routingAppender{routes: make(map[int]*logWriter, len(routes))}
+	shared := make(map[io.Writer]*logWriter, len(routes))
+	for level, w := range routes {
+		lg, ok := shared[w]
+		if !ok {
+			lg = newLogWriter(w)
+			shared[w] = lg
+		}
+		if level == RouteDefault {
+			r.fallback = lg
+		} else {
+			r.routes[level] = lg
+		}
+	}
+	return r
+}
+
+func (r *routingAppender) Init(config string) error {
+	return nil
+}
+
+func (r *routingAppender) route(level int) *logWriter {
+	if lg, ok := r.routes[level]; ok {
+		return lg
+	}
+	return r.fallback
+}
+
+func (r *routingAppender) WriteMsg(when time.Time, msg string, level int) error {
+	if lg := r.route(level); lg != nil {
+		lg.println(when, msg, level)
+	}
+	return nil
+}
+
+func (r *routingAppender) WriteEntry(e *Entry) error {
+	if lg := r.route(e.Level); lg != nil {
+		lg.printEntry(e, nil)
+	}
+	return nil
+}
+
+func (r *routingAppender) Flush() {
+
+}
+
+//Destroy leave the writers open, they belong to the caller
+func (r *routingAppender) Destroy() {
+
+}
@@ -0,0 +1,31 @@
+package logg
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRoutingAppender(t *testing.T) {
+	var errs, infos, others bytes.Buffer
+	log := NewLogger(10)
+	log.AddAppender("route", NewRoutingAppender(map[int]io.Writer{
+		LevelError:   &errs,
+		LevelInfo:    &infos,
+		RouteDefault: &others,
+	}))
+	log.Error("failed")
+	log.Info("started")
+	log.Debug("details")
+	log.Close()
+
+	check := func(name string, buf *bytes.Buffer, want string) {
+		if got := buf.String(); strings.Count(got, "\n") != 1 || !strings.HasSuffix(got, want+"\n") {
+			t.Fatalf("%s writer got %q, want only %q", name, got, want)
+		}
+	}
+	check("error", &errs, "[E] failed")
+	check("info", &infos, "[I] started")
+	check("default", &others, "[D] details")
+}
@@ -0,0 +1,182 @@
+//go:build s3
+// +build s3
+
+//Package s3logg ships the files rotated by logg's file appender to S3 or an
+//S3-compatible storage, build with `-tags s3`
+package s3logg
+
+import (
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/colefan/logg"
+)
+
+//Uploader gzip a rotated file, PUT it to Endpoint/Bucket/Prefix<name>.gz
+//signed with AWS signature v4, then delete it locally
+type Uploader struct {
+	Endpoint  string //like "https://s3.eu-west-1.amazonaws.com" or "http://minio:9000"
+	Region    string
+	Bucket    string
+	Prefix    string //key prefix, like "logs/host1/"
+	AccessKey string
+	SecretKey string
+	KeepLocal bool         //keep the rotated file after the upload
+	Client    *http.Client //http.DefaultClient when nil
+	//OnError report failed uploads, they are printed to stderr when nil
+	OnError func(path string, err error)
+}
+
+//Enable upload every file rotated by log's file appenders with u
+func Enable(log *logg.BaseLogger, u *Uploader) {
+	log.OnRotate(u.Hook)
+}
+
+//Hook upload path and report the error, to be passed to OnRotate
+func (u *Uploader) Hook(path string) {
+	if err := u.Upload(path); err != nil {
+		if u.OnError != nil {
+			u.OnError(path, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "s3logg: upload %s error %v\n", path, err)
+		}
+	}
+}
+
+//Upload compress path next to it, upload the archive then remove both files
+func (u *Uploader) Upload(path string) error {
+	gzPath := path + ".gz"
+	sum, size, err := compress(path, gzPath)
+	defer os.Remove(gzPath)
+	if err != nil {
+		return err
+	}
+	body, err := os.Open(gzPath)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	key := u.Prefix + filepath.Base(gzPath)
+	req, err := http.NewRequest(http.MethodPut, strings.TrimSuffix(u.Endpoint, "/")+"/"+u.Bucket+"/"+key, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/gzip")
+	u.sign(req, hex.EncodeToString(sum), time.Now().UTC())
+
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.New("s3logg: PUT " + key + " " + resp.Status + " " + string(msg))
+	}
+	if !u.KeepLocal {
+		return os.Remove(path)
+	}
+	return nil
+}
+
+//compress gzip src into dst, return the sha256 and size of dst
+func compress(src, dst string) ([]byte, int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0660)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer out.Close()
+	hash := sha256.New()
+	counter := &countWriter{w: io.MultiWriter(out, hash)}
+	zw := gzip.NewWriter(counter)
+	if _, err := io.Copy(zw, in); err != nil {
+		return nil, 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, 0, err
+	}
+	return hash.Sum(nil), counter.n, out.Close()
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+//sign add the AWS signature v4 headers to req
+func (u *Uploader) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + u.Region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+u.SecretKey), date)
+	for _, part := range []string{u.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+u.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+//escapePath uri-encode every byte but the unreserved ones and '/', as
+//signature v4 expects
+func escapePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
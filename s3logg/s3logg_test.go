@@ -0,0 +1,54 @@
+//go:build s3
+// +build s3
+
+package s3logg
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpload(t *testing.T) {
+	var gotPath, gotAuth, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(zr)
+		gotBody = string(data)
+	}))
+	defer srv.Close()
+
+	rotated := filepath.Join(t.TempDir(), "app_2026-01-02_001.log")
+	if err := os.WriteFile(rotated, []byte("line 1\nline 2\n"), 0660); err != nil {
+		t.Fatal(err)
+	}
+	u := &Uploader{Endpoint: srv.URL, Region: "us-east-1", Bucket: "logs", Prefix: "host1/", AccessKey: "AK", SecretKey: "SK"}
+	if err := u.Upload(rotated); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/logs/host1/app_2026-01-02_001.log.gz" {
+		t.Fatalf("uploaded to %s", gotPath)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AK/") {
+		t.Fatalf("request not signed: %q", gotAuth)
+	}
+	if gotBody != "line 1\nline 2\n" {
+		t.Fatalf("uploaded content %q", gotBody)
+	}
+	if _, err := os.Stat(rotated); !os.IsNotExist(err) {
+		t.Fatalf("rotated file should be removed after upload, stat error %v", err)
+	}
+	if _, err := os.Stat(rotated + ".gz"); !os.IsNotExist(err) {
+		t.Fatalf("archive should be removed after upload, stat error %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package logg
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const samplerCounters = 1024
+
+//sampler let through the first messages of each level and text per tick,
+//then one out of thereafter, like zap's sampler
+type sampler struct {
+	tick       int64
+	first      uint64
+	thereafter uint64
+	counters   [LevelDebug + 1][samplerCounters]samplerCounter
+}
+
+type samplerCounter struct {
+	resetAt int64
+	n       uint64
+}
+
+//SetSampling keep per tick the first messages of each level and text, then
+//one in thereafter (none when 0), to bound the cost of hot log statements.
+//Messages with the same text hash may share a counter. A tick <= 0 disables it
+func (log *BaseLogger) SetSampling(tick time.Duration, first, thereafter int) {
+	if tick <= 0 {
+		log.sampler.Store((*sampler)(nil))
+		return
+	}
+	log.sampler.Store(&sampler{tick: int64(tick), first: uint64(first), thereafter: uint64(thereafter)})
+}
+
+func (s *sampler) allow(level int, msg string, now time.Time) bool {
+	//fnv-1a
+	h := uint32(2166136261)
+	for i := 0; i < len(msg); i++ {
+		h ^= uint32(msg[i])
+		h *= 16777619
+	}
+	n := s.counters[level][h%samplerCounters].inc(now.UnixNano(), s.tick)
+	if n <= s.first {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.first)%s.thereafter == 0
+}
+
+//inc count a message at t, restarting the count every tick
+func (c *samplerCounter) inc(t int64, tick int64) uint64 {
+	resetAt := atomic.LoadInt64(&c.resetAt)
+	if resetAt > t {
+		return atomic.AddUint64(&c.n, 1)
+	}
+	atomic.StoreUint64(&c.n, 1)
+	if !atomic.CompareAndSwapInt64(&c.resetAt, resetAt, t+tick) {
+		return atomic.AddUint64(&c.n, 1)
+	}
+	return 1
+}
+
+//levelSamplingSummary how often a level's sampled out count is logged, a
+//var for the tests
+var levelSamplingSummary = 10 * time.Second
+
+//levelSampler keep one message in every[level], the first one included
+type levelSampler struct {
+	every     [LevelDebug + 1]uint64
+	seen      [LevelDebug + 1]uint64
+	dropped   [LevelDebug + 1]uint64
+	summaryAt [LevelDebug + 1]int64 //UnixNano of the next summary
+	stop      chan struct{}
+	exited    chan struct{}
+	haltOnce  sync.Once
+}
+
+//SetLevelSampling keep one message in every[level] for the levels in every,
+//0 or 1 or a missing level keep them all. Every 10 seconds the count of
+//sampled out messages of a level is logged at that level, as well as on Flush,
+//Close and when the sampling is replaced. Nil disables it
+func (log *BaseLogger) SetLevelSampling(every map[int]int) {
+	s := &levelSampler{stop: make(chan struct{}), exited: make(chan struct{})}
+	enabled := false
+	next := time.Now().Add(levelSamplingSummary).UnixNano()
+	for level := range s.every {
+		if n := every[level]; n > 1 {
+			s.every[level] = uint64(n)
+			enabled = true
+		}
+		s.summaryAt[level] = next
+	}
+	if !enabled {
+		s = nil
+	}
+	log.samplingLock.Lock()
+	defer log.samplingLock.Unlock()
+	if old, _ := log.levelSampler.Load().(*levelSampler); old != nil {
+		old.halt()
+		log.logSummaries(old, time.Now(), true)
+	}
+	log.levelSampler.Store(s)
+	if s != nil {
+		go log.summarize(s)
+	}
+}
+
+//summarize log the due summaries of s until it is halted
+func (log *BaseLogger) summarize(s *levelSampler) {
+	defer close(s.exited)
+	ticker := time.NewTicker(levelSamplingSummary)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			log.logSummaries(s, now, false)
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+//halt stop the summarize goroutine and wait for it
+func (s *levelSampler) halt() {
+	s.haltOnce.Do(func() { close(s.stop) })
+	<-s.exited
+}
+
+//logSummaries log the summary of each level of s, due or not when force
+func (log *BaseLogger) logSummaries(s *levelSampler, now time.Time, force bool) {
+	if atomic.LoadInt32(&log.utc) == 1 {
+		now = now.UTC()
+	}
+	for level := range s.every {
+		if msg := s.summary(level, now, force); len(msg) > 0 {
+			e := log.logMsgPool.Get().(*Entry)
+			*e = Entry{When: now, Level: level, Msg: msg, origin: log}
+			log.send(e)
+		}
+	}
+}
+
+//flushSummaries log what the level sampling dropped so far, before a Flush
+//or a Close, which also stops the summaries
+func (log *BaseLogger) flushSummaries(stop bool) {
+	log.samplingLock.Lock()
+	defer log.samplingLock.Unlock()
+	s, _ := log.levelSampler.Load().(*levelSampler)
+	if s == nil {
+		return
+	}
+	if stop {
+		s.halt()
+		log.levelSampler.Store((*levelSampler)(nil))
+	}
+	log.logSummaries(s, time.Now(), true)
+}
+
+func (s *levelSampler) allow(level int) bool {
+	if s.every[level] == 0 {
+		return true
+	}
+	if (atomic.AddUint64(&s.seen[level], 1)-1)%s.every[level] == 0 {
+		return true
+	}
+	atomic.AddUint64(&s.dropped[level], 1)
+	return false
+}
+
+//summary the message reporting what level lost since the last one, empty when
+//nothing was sampled out or, unless force, it is not due
+func (s *levelSampler) summary(level int, now time.Time, force bool) string {
+	at := atomic.LoadInt64(&s.summaryAt[level])
+	if (!force && now.UnixNano() < at) || atomic.LoadUint64(&s.dropped[level]) == 0 {
+		return ""
+	}
+	if !atomic.CompareAndSwapInt64(&s.summaryAt[level], at, now.Add(levelSamplingSummary).UnixNano()) {
+		return ""
+	}
+	n := atomic.SwapUint64(&s.dropped[level], 0)
+	return "logg: sampled out " + strconv.FormatUint(n, 10) + " " + levelNames[level] + " messages"
+}
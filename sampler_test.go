@@ -0,0 +1,93 @@
+package logg
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetSampling(t *testing.T) {
+	log := NewLogger(10)
+	r := addRecorder(log, "record")
+	log.SetSampling(time.Hour, 2, 3)
+	for i := 0; i < 10; i++ {
+		log.Info("sampled")
+		log.Error("sampled")
+	}
+	log.SetSampling(0, 0, 0)
+	log.Info("unsampled")
+	//first 2 then the 5th and 8th of each level
+	if n := len(r.Lines()); n != 9 {
+		t.Fatalf("%d messages kept, want 9: %q", n, r.Lines())
+	}
+}
+
+func TestSetLevelSampling(t *testing.T) {
+	log := NewLogger(10)
+	r := addRecorder(log, "record")
+	log.SetLevelSampling(map[int]int{LevelInfo: 10, LevelDebug: 10, LevelWarn: 1})
+	for i := 0; i < 100; i++ {
+		log.Error("error")
+		log.Warn("warn")
+		log.Info("info")
+		log.Debug("debug")
+	}
+	count := func(level string) int {
+		n := 0
+		for _, line := range r.Lines() {
+			if strings.Contains(line, level) {
+				n++
+			}
+		}
+		return n
+	}
+	if count("[E] error") != 100 || count("[W] warn") != 100 {
+		t.Fatalf("errors and warns must all be kept: %d %d", count("[E] error"), count("[W] warn"))
+	}
+	if count("[I] info") != 10 || count("[D] debug") != 10 {
+		t.Fatalf("info and debug must be sampled: %d %d", count("[I] info"), count("[D] debug"))
+	}
+	log.InfoAt(time.Now().Add(time.Minute), "info")
+	if count("[I] logg: sampled out 90 info messages") != 1 {
+		t.Fatalf("missing the info summary: %q", r.Lines())
+	}
+	log.SetLevelSampling(nil)
+	log.Debug("unsampled")
+	if count("[D] unsampled") != 1 {
+		t.Fatal("nil must disable the level sampling")
+	}
+}
+
+func TestLevelSamplingSummaryAfterBurst(t *testing.T) {
+	interval := levelSamplingSummary
+	levelSamplingSummary = 20 * time.Millisecond
+	defer func() { levelSamplingSummary = interval }()
+	log := NewLogger(10)
+	r := addRecorder(log, "record")
+	log.SetLevelSampling(map[int]int{LevelInfo: 10})
+	for i := 0; i < 30; i++ {
+		log.Info("burst")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(strings.Join(r.Lines(), "|"), "[I] logg: sampled out 27 info messages") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no summary after the burst: %q", r.Lines())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	log.SetLevelSampling(map[int]int{LevelDebug: 5})
+	for i := 0; i < 5; i++ {
+		log.Debug("burst")
+	}
+	log.Flush()
+	if last := r.Lines()[len(r.Lines())-1]; last != "[D] logg: sampled out 4 debug messages" {
+		t.Fatalf("Flush did not log the summary, last line %q", last)
+	}
+	log.Debug("burst")
+	log.Debug("burst")
+	log.Close()
+	if last := r.Lines()[len(r.Lines())-1]; last != "[D] logg: sampled out 1 debug messages" {
+		t.Fatalf("Close did not log the summary, last line %q", last)
+	}
+}
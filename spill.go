@@ -0,0 +1,284 @@
+package logg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+//spill queue the messages of an async logger whose channel is full in a file,
+//a goroutine replays them in order when the channel has room again
+type spill struct {
+	log     *BaseLogger
+	lock    sync.Mutex
+	drained *sync.Cond
+	path    string
+	w       *os.File
+	r       *os.File
+	reader  *bufio.Reader
+	pending int    //records in the file not yet queued in msgChan
+	spilled uint64 //records ever written
+	queued  uint64 //records ever queued back in msgChan
+	wake    chan struct{}
+	quit    chan struct{}
+	exited  chan struct{}
+	held    *Entry //read but not queued when the replay was stopped
+}
+
+//spillRecord the file form of an entry, field values keep their text
+//rendering but not their Go type
+type spillRecord struct {
+	When      time.Time    `json:"t"`
+	Level     int          `json:"l"`
+	Msg       string       `json:"m"`
+	Caller    string       `json:"c,omitempty"`
+	Func      string       `json:"f,omitempty"`
+	Version   string       `json:"v,omitempty"`
+	Seq       uint64       `json:"s,omitempty"`
+	Goroutine uint64       `json:"g,omitempty"`
+	Fields    []spillField `json:"x,omitempty"`
+}
+
+type spillField struct {
+	Key   string       `json:"k"`
+	Value interface{}  `json:"v"`
+	Group []spillField `json:"g,omitempty"`
+	Type  string       `json:"y,omitempty"` //d a time.Duration in ns, t a time.Time
+}
+
+//SetSpillFile let an async logger write the messages not fitting in its
+//channel to path instead of blocking, they are replayed in order when the
+//channel has room. Field values of spilled messages are converted to strings,
+//numbers, bools, slices or maps. Messages forwarded by tee appenders are never
+//spilled. Call it before Async, Close removes the file
+func (log *BaseLogger) SetSpillFile(path string) error {
+	if log.async {
+		return errors.New("logg: SetSpillFile must be called before Async")
+	}
+	if log.spill != nil {
+		return errors.New("logg: spill file already set to " + log.spill.path)
+	}
+	w, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	r, err := os.Open(path)
+	if err != nil {
+		w.Close()
+		return err
+	}
+	s := &spill{
+		log:    log,
+		path:   path,
+		w:      w,
+		r:      r,
+		reader: bufio.NewReader(r),
+		wake:   make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+	s.drained = sync.NewCond(&s.lock)
+	log.spill = s
+	return nil
+}
+
+//push queue e in msgChan, or in the file when msgChan is full or older
+//messages are still in the file
+func (s *spill) push(e *Entry) {
+	s.lock.Lock()
+	if s.pending == 0 {
+		select {
+		case s.log.msgChan <- e:
+			s.lock.Unlock()
+			return
+		default:
+		}
+	}
+	data, err := json.Marshal(newSpillRecord(e))
+	if err == nil {
+		_, err = s.w.Write(append(data, '\n'))
+	}
+	if err != nil {
+		s.lock.Unlock()
+		fmt.Fprintf(os.Stderr, "logg: spill file %s error:%v\n", s.path, err)
+		s.log.msgChan <- e
+		return
+	}
+	s.pending++
+	s.spilled++
+	s.lock.Unlock()
+	s.log.logMsgPool.Put(e)
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *spill) replay() {
+	defer close(s.exited)
+	for {
+		select {
+		case <-s.wake:
+		case <-s.quit:
+			return
+		}
+		for {
+			e, ok := s.next()
+			if !ok {
+				break
+			}
+			select {
+			case s.log.msgChan <- e:
+				s.done()
+			case <-s.quit:
+				s.held = e
+				return
+			}
+		}
+	}
+}
+
+//next read the oldest spilled message
+func (s *spill) next() (*Entry, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for s.pending > 0 {
+		line, err := s.reader.ReadBytes('\n')
+		var rec spillRecord
+		if err == nil {
+			d := json.NewDecoder(bytes.NewReader(line))
+			d.UseNumber()
+			err = d.Decode(&rec)
+		}
+		if err == nil {
+			e := s.log.logMsgPool.Get().(*Entry)
+			*e = Entry{When: rec.When, Level: rec.Level, Msg: rec.Msg, Caller: rec.Caller, Func: rec.Func,
+				Version: rec.Version, Seq: rec.Seq, Goroutine: rec.Goroutine, Fields: spillFields(rec.Fields), origin: s.log}
+			return e, true
+		}
+		fmt.Fprintf(os.Stderr, "logg: spill file %s read error:%v\n", s.path, err)
+		s.markQueued()
+	}
+	return nil, false
+}
+
+func (s *spill) done() {
+	s.lock.Lock()
+	s.markQueued()
+	s.lock.Unlock()
+}
+
+//markQueued count one record out of the file, which is emptied once all of
+//them are, must hold the lock
+func (s *spill) markQueued() {
+	s.pending--
+	s.queued++
+	if s.pending == 0 {
+		s.w.Truncate(0)
+		s.r.Seek(0, io.SeekStart)
+		s.reader.Reset(s.r)
+	}
+	s.drained.Broadcast()
+}
+
+//wait until the messages spilled so far are queued in msgChan, the workers
+//must run
+func (s *spill) wait() {
+	s.lock.Lock()
+	for target := s.spilled; s.queued < target; {
+		s.drained.Wait()
+	}
+	s.lock.Unlock()
+}
+
+//stop the replay goroutine then write what it left directly, the workers
+//must be stopped and msgChan empty
+func (s *spill) stop() {
+	if s.log.async {
+		close(s.quit)
+		<-s.exited
+	}
+	if s.held != nil {
+		s.log.writeToAppender(s.held)
+		s.held = nil
+	}
+	for {
+		e, ok := s.next()
+		if !ok {
+			break
+		}
+		s.log.writeToAppender(e)
+		s.done()
+	}
+	s.w.Close()
+	s.r.Close()
+	os.Remove(s.path)
+}
+
+func newSpillRecord(e *Entry) *spillRecord {
+	return &spillRecord{When: e.When, Level: e.Level, Msg: e.Msg, Caller: e.Caller, Func: e.Func,
+		Version: e.Version, Seq: e.Seq, Goroutine: e.Goroutine, Fields: newSpillFields(e.Fields)}
+}
+
+func newSpillFields(fields []Field) []spillField {
+	if len(fields) == 0 {
+		return nil
+	}
+	out := make([]spillField, 0, len(fields))
+	for _, f := range fields {
+		sf := spillField{Key: f.Key}
+		switch v := f.Value.(type) {
+		case time.Duration:
+			sf.Value, sf.Type = int64(v), "d"
+		case time.Time:
+			sf.Value, sf.Type = v.Format(time.RFC3339Nano), "t"
+		case []Field:
+			//an empty group renders nothing
+			if sf.Group = newSpillFields(v); sf.Group == nil {
+				continue
+			}
+		case error:
+			sf.Value = v.Error()
+		case fmt.Stringer:
+			sf.Value = v.String()
+		default:
+			if _, err := json.Marshal(v); err != nil {
+				sf.Value = fmt.Sprint(v)
+			} else {
+				sf.Value = v
+			}
+		}
+		out = append(out, sf)
+	}
+	return out
+}
+
+func spillFields(fields []spillField) []Field {
+	if len(fields) == 0 {
+		return nil
+	}
+	out := make([]Field, len(fields))
+	for i, f := range fields {
+		out[i].Key = f.Key
+		switch {
+		case f.Group != nil:
+			out[i].Value = spillFields(f.Group)
+		case f.Type == "d":
+			n, _ := f.Value.(json.Number)
+			ns, _ := n.Int64()
+			out[i].Value = time.Duration(ns)
+		case f.Type == "t":
+			s, _ := f.Value.(string)
+			out[i].Value, _ = time.Parse(time.RFC3339Nano, s)
+		default:
+			out[i].Value = f.Value
+		}
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package logg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type slowAppender struct {
+	Recorder
+	delay time.Duration
+}
+
+func (s *slowAppender) WriteMsg(when time.Time, msg string, level int) error {
+	time.Sleep(s.delay)
+	return s.Recorder.WriteMsg(when, msg, level)
+}
+
+func TestSpillFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill")
+	log := NewLogger(10)
+	slow := &slowAppender{delay: 100 * time.Microsecond}
+	log.AddAppender("slow", slow)
+	if err := log.SetSpillFile(path); err != nil {
+		t.Fatal(err)
+	}
+	log.Async()
+	if err := log.SetSpillFile(path); err == nil {
+		t.Fatal("SetSpillFile after Async should fail")
+	}
+	for i := 0; i < 1000; i++ {
+		log.With(Field{"i", i}, Group("g", Field{"d", time.Second})).Info("burst")
+		if i == 500 {
+			log.Flush()
+			if n := len(slow.Lines()); n != 501 {
+				t.Fatalf("Flush wrote %d messages, want 501", n)
+			}
+		}
+	}
+	if log.spill.spilled == 0 {
+		t.Fatal("the burst did not spill")
+	}
+	log.Close()
+	lines := slow.Lines()
+	if len(lines) != 1000 {
+		t.Fatalf("got %d messages, want 1000", len(lines))
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, "burst i="+strconv.Itoa(i)+" g.d=1s") {
+			t.Fatalf("message %d is %q", i, line)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Close should remove the spill file: %v", err)
+	}
+}
+
+func TestSpillRoundTrip(t *testing.T) {
+	log := NewLogger(0)
+	if err := log.SetSpillFile(filepath.Join(t.TempDir(), "spill")); err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	in := Entry{When: time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC), Level: LevelWarn, Msg: "spilled",
+		Caller: "spill_test.go:12", Func: "logg.TestSpillRoundTrip", Version: "1.4.2", Seq: 7, Goroutine: 9,
+		Fields: []Field{{"s", "v"}, {"d", 1500 * time.Millisecond}, {"t", time.Date(2021, 5, 6, 7, 8, 9, 10, time.UTC)},
+			Group("g", Field{"b", true})}}
+	typ := reflect.TypeOf(in)
+	for i := 0; i < typ.NumField(); i++ {
+		if f := typ.Field(i); f.PkgPath == "" && reflect.ValueOf(in).Field(i).IsZero() {
+			t.Fatalf("set Entry.%s in the test so it is checked", f.Name)
+		}
+	}
+	e := log.logMsgPool.Get().(*Entry)
+	*e = in
+	log.spill.push(e)
+	out, ok := log.spill.next()
+	if !ok {
+		t.Fatal("nothing spilled")
+	}
+	log.spill.done()
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if got, want := reflect.ValueOf(*out).Field(i).Interface(), reflect.ValueOf(in).Field(i).Interface(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Entry.%s = %#v after the spill file, want %#v", f.Name, got, want)
+		}
+	}
+}
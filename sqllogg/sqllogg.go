@@ -0,0 +1,198 @@
+//Package sqllogg registers the "sql" appender inserting messages into a
+//database table with database/sql. Import it and the database driver:
+//
+//	import _ "github.com/colefan/logg/sqllogg"
+//	import _ "github.com/lib/pq"
+//
+//	log.SetAppender("sql", `{"driver":"postgres","dsn":"...","table":"logs","autocreate":true}`)
+package sqllogg
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/colefan/logg"
+)
+
+var levelNames = []string{"fatal", "error", "warn", "info", "debug"}
+
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+type row struct {
+	when  time.Time
+	level string
+	msg   string
+}
+
+type sqlAppender struct {
+	sync.Mutex
+	Driver        string `json:"driver"`
+	DSN           string `json:"dsn"`
+	Table         string `json:"table"`
+	AutoCreate    bool   `json:"autocreate"`
+	Placeholder   string `json:"placeholder"` //"?" or "$" (postgres), guessed from the driver
+	Level         int    `json:"level"`
+	MaxLevel      int    `json:"maxlevel"`
+	BatchSize     int    `json:"batchsize"`     //rows per insert transaction
+	FlushInterval int    `json:"flushinterval"` //milliseconds before an incomplete batch is inserted
+	MaxPending    int    `json:"maxpending"`    //rows kept while the database fails, the oldest are dropped
+	db            *sql.DB
+	insert        string
+	pending       []row
+	timer         *time.Timer
+}
+
+func newSQLAppender() logg.Appender {
+	return &sqlAppender{
+		Level:         logg.LevelDebug,
+		BatchSize:     100,
+		FlushInterval: 1000,
+		MaxPending:    10000,
+	}
+}
+
+//Init config like
+//{
+//"driver":"postgres",
+//"dsn":"postgres://localhost/app",
+//"table":"logs",
+//"autocreate":true,
+//"batchsize":100,
+//"flushinterval":1000,
+//"maxpending":10000,
+//}
+func (s *sqlAppender) Init(config string) error {
+	if err := json.Unmarshal([]byte(config), s); err != nil {
+		return err
+	}
+	if len(s.Driver) == 0 || len(s.DSN) == 0 {
+		return errors.New("sql appender config must have driver and dsn")
+	}
+	if !tableName.MatchString(s.Table) {
+		return errors.New("sql appender invalid table " + strconv.Quote(s.Table))
+	}
+	if s.BatchSize <= 0 {
+		s.BatchSize = 1
+	}
+	if len(s.Placeholder) == 0 {
+		s.Placeholder = "?"
+		if s.Driver == "postgres" || s.Driver == "pgx" {
+			s.Placeholder = "$"
+		}
+	}
+	if s.Placeholder == "$" {
+		s.insert = "INSERT INTO " + s.Table + " (time, level, message) VALUES ($1, $2, $3)"
+	} else {
+		s.insert = "INSERT INTO " + s.Table + " (time, level, message) VALUES (?, ?, ?)"
+	}
+
+	db, err := sql.Open(s.Driver, s.DSN)
+	if err != nil {
+		return err
+	}
+	if s.AutoCreate {
+		_, err = db.Exec("CREATE TABLE IF NOT EXISTS " + s.Table + " (time TIMESTAMP, level VARCHAR(8), message TEXT)")
+	} else {
+		var rows *sql.Rows
+		if rows, err = db.Query("SELECT time, level, message FROM " + s.Table + " WHERE 1=0"); err == nil {
+			err = rows.Close()
+		}
+	}
+	if err != nil {
+		db.Close()
+		return errors.New("sql appender table " + s.Table + ": " + err.Error())
+	}
+	s.db = db
+	return nil
+}
+
+func (s *sqlAppender) WriteMsg(when time.Time, msg string, level int) error {
+	if level > s.Level || level < s.MaxLevel {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.MaxPending > 0 && len(s.pending) >= s.MaxPending {
+		s.pending = s.pending[1:]
+	}
+	s.pending = append(s.pending, row{when, levelNames[level], msg})
+	if len(s.pending) >= s.BatchSize {
+		return s.insertPending()
+	}
+	if s.timer == nil && s.FlushInterval > 0 {
+		s.timer = time.AfterFunc(time.Duration(s.FlushInterval)*time.Millisecond, func() {
+			s.Lock()
+			s.timer = nil
+			s.insertPending()
+			s.Unlock()
+		})
+	}
+	return nil
+}
+
+//insertPending insert the pending rows in one transaction, they are kept for
+//the next try when it fails. database/sql reconnects on its own. Must hold the lock
+func (s *sqlAppender) insertPending() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(s.insert)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, r := range s.pending {
+		if _, err = stmt.Exec(r.when, r.level, r.msg); err != nil {
+			stmt.Close()
+			tx.Rollback()
+			return err
+		}
+	}
+	stmt.Close()
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	s.pending = s.pending[:0]
+	return nil
+}
+
+//Flush insert the pending rows
+func (s *sqlAppender) Flush() {
+	s.Lock()
+	if err := s.insertPending(); err != nil {
+		logStderr(err)
+	}
+	s.Unlock()
+}
+
+func (s *sqlAppender) Destroy() {
+	s.Lock()
+	defer s.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if err := s.insertPending(); err != nil {
+		logStderr(err)
+	}
+	s.db.Close()
+}
+
+func logStderr(err error) {
+	fmt.Fprintf(os.Stderr, "sql appender insert error:%v\n", err)
+}
+
+func init() {
+	logg.RegisterAppender("sql", newSQLAppender)
+}
@@ -0,0 +1,118 @@
+package sqllogg
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/colefan/logg"
+)
+
+//fakeDB record the statements run through the "logg-fake" driver
+type fakeDB struct {
+	sync.Mutex
+	tables map[string]bool
+	rows   [][]driver.Value
+}
+
+var fake = &fakeDB{tables: map[string]bool{"existing": true}}
+
+func init() {
+	sql.Register("logg-fake", fakeDriver{})
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	fake.Lock()
+	defer fake.Unlock()
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE IF NOT EXISTS "):
+		fake.tables[strings.Fields(s.query)[5]] = true
+	case strings.HasPrefix(s.query, "INSERT INTO "):
+		fake.rows = append(fake.rows, args)
+	default:
+		return nil, errors.New("unexpected exec " + s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	fake.Lock()
+	defer fake.Unlock()
+	if !fake.tables[strings.Fields(s.query)[5]] {
+		return nil, errors.New("no such table")
+	}
+	return fakeRows{}, nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string              { return []string{"time", "level", "message"} }
+func (fakeRows) Close() error                   { return nil }
+func (fakeRows) Next(dest []driver.Value) error { return io.EOF }
+
+func TestSQLAppender(t *testing.T) {
+	fake.Lock()
+	fake.tables, fake.rows = map[string]bool{"existing": true}, nil
+	fake.Unlock()
+	if err := newSQLAppender().Init(`{"driver":"logg-fake","dsn":"x","table":"missing"}`); err == nil {
+		t.Fatal("a missing table must fail without autocreate")
+	}
+	if err := newSQLAppender().Init(`{"driver":"logg-fake","dsn":"x","table":"logs; DROP TABLE x"}`); err == nil {
+		t.Fatal("an invalid table name must fail")
+	}
+	if err := newSQLAppender().Init(`{"driver":"logg-fake","dsn":"x","table":"existing"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	log := logg.NewLogger(10)
+	if err := log.SetAppender("sql", `{"driver":"logg-fake","dsn":"x","table":"logs","autocreate":true,"batchsize":2,"flushinterval":0}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("one")
+	log.Error("two")
+	fake.Lock()
+	batched := len(fake.rows)
+	fake.Unlock()
+	if batched != 2 {
+		t.Fatalf("a full batch should be inserted, got %d rows", batched)
+	}
+	log.Warn("three")
+	log.Flush()
+	log.Close()
+
+	fake.Lock()
+	defer fake.Unlock()
+	if len(fake.rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(fake.rows))
+	}
+	last := fake.rows[2]
+	if last[1] != "warn" || last[2] != "[W] three" {
+		t.Fatalf("bad row %v", last)
+	}
+}
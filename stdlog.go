@@ -0,0 +1,49 @@
+package logg
+
+import (
+	"io"
+	stdlog "log"
+	"sync"
+)
+
+//stdCapture the standard logger state saved by CaptureStandardLog
+var stdCapture struct {
+	sync.Mutex
+	pipe   io.WriteCloser
+	output io.Writer
+	flags  int
+	prefix string
+}
+
+//CaptureStandardLog send the output of the standard log package, used by
+//many dependencies, to log at level. logg adds its own timestamp so the
+//standard flags are cleared until RestoreStandardLog
+func (log *BaseLogger) CaptureStandardLog(level int) {
+	stdCapture.Lock()
+	defer stdCapture.Unlock()
+	if stdCapture.pipe == nil {
+		stdCapture.output = stdlog.Writer()
+		stdCapture.flags = stdlog.Flags()
+		stdCapture.prefix = stdlog.Prefix()
+	} else {
+		stdCapture.pipe.Close()
+	}
+	stdCapture.pipe = log.LevelPipe(level)
+	stdlog.SetFlags(0)
+	stdlog.SetPrefix("")
+	stdlog.SetOutput(stdCapture.pipe)
+}
+
+//RestoreStandardLog undo CaptureStandardLog
+func RestoreStandardLog() {
+	stdCapture.Lock()
+	defer stdCapture.Unlock()
+	if stdCapture.pipe == nil {
+		return
+	}
+	stdlog.SetOutput(stdCapture.output)
+	stdlog.SetFlags(stdCapture.flags)
+	stdlog.SetPrefix(stdCapture.prefix)
+	stdCapture.pipe.Close()
+	stdCapture.pipe = nil
+}
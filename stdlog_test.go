@@ -0,0 +1,29 @@
+package logg
+
+import (
+	"bytes"
+	stdlog "log"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCaptureStandardLog(t *testing.T) {
+	log := NewLogger(10)
+	r := addRecorder(log, "record")
+	var previous bytes.Buffer
+	stdlog.SetOutput(&previous)
+	log.CaptureStandardLog(LevelWarn)
+	stdlog.Print("from a dependency")
+	stdlog.Printf("code %d", 42)
+	RestoreStandardLog()
+	stdlog.Print("after restore")
+
+	if got, want := r.Lines(), []string{"[W] from a dependency", "[W] code 42"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("captured %q, want %q", got, want)
+	}
+	if !bytes.HasSuffix(previous.Bytes(), []byte("after restore\n")) || stdlog.Flags() != stdlog.LstdFlags {
+		t.Fatalf("standard log not restored: %q, flags %d", previous.String(), stdlog.Flags())
+	}
+	stdlog.SetOutput(os.Stderr)
+}
@@ -0,0 +1,112 @@
+package logg
+
+import "fmt"
+
+//badKey the key given to the last value of an odd keysAndValues
+const badKey = "!BADKEY"
+
+//kvFields pair keysAndValues into fields, keys which are not strings are
+//formatted with fmt.Sprint and a trailing value without key gets badKey
+func kvFields(keysAndValues []interface{}) []Field {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+	fields := make([]Field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 == len(keysAndValues) {
+			fields = append(fields, Field{badKey, keysAndValues[i]})
+			break
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		fields = append(fields, Field{key, keysAndValues[i+1]})
+	}
+	return fields
+}
+
+//Fatalw log msg at LevelFatal with fields paired from keysAndValues,
+//like Fatalw("msg", "key1", v1, "key2", v2)
+func (log *BaseLogger) Fatalw(msg string, keysAndValues ...interface{}) {
+	if log.skipped(LevelFatal) {
+		return
+	}
+	log.writeMsg(LevelFatal, msg, kvFields(keysAndValues))
+}
+
+//Errorw log msg at LevelError with fields paired from keysAndValues,
+//like Errorw("msg", "key1", v1, "key2", v2)
+func (log *BaseLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	if log.skipped(LevelError) {
+		return
+	}
+	log.writeMsg(LevelError, msg, kvFields(keysAndValues))
+}
+
+//Warnw log msg at LevelWarn with fields paired from keysAndValues,
+//like Warnw("msg", "key1", v1, "key2", v2)
+func (log *BaseLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	if log.skipped(LevelWarn) {
+		return
+	}
+	log.writeMsg(LevelWarn, msg, kvFields(keysAndValues))
+}
+
+//Infow log msg at LevelInfo with fields paired from keysAndValues,
+//like Infow("msg", "key1", v1, "key2", v2)
+func (log *BaseLogger) Infow(msg string, keysAndValues ...interface{}) {
+	if log.skipped(LevelInfo) {
+		return
+	}
+	log.writeMsg(LevelInfo, msg, kvFields(keysAndValues))
+}
+
+//Debugw log msg at LevelDebug with fields paired from keysAndValues,
+//like Debugw("msg", "key1", v1, "key2", v2)
+func (log *BaseLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	if log.skipped(LevelDebug) {
+		return
+	}
+	log.writeMsg(LevelDebug, msg, kvFields(keysAndValues))
+}
+
+//Fatalw log.Fatalw inside the logger's fields and groups
+func (l *FieldLogger) Fatalw(msg string, keysAndValues ...interface{}) {
+	if l.base.skipped(LevelFatal) {
+		return
+	}
+	l.base.writeMsg(LevelFatal, msg, l.With(kvFields(keysAndValues)...).fields)
+}
+
+//Errorw log.Errorw inside the logger's fields and groups
+func (l *FieldLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	if l.base.skipped(LevelError) {
+		return
+	}
+	l.base.writeMsg(LevelError, msg, l.With(kvFields(keysAndValues)...).fields)
+}
+
+//Warnw log.Warnw inside the logger's fields and groups
+func (l *FieldLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	if l.base.skipped(LevelWarn) {
+		return
+	}
+	l.base.writeMsg(LevelWarn, msg, l.With(kvFields(keysAndValues)...).fields)
+}
+
+//Infow log.Infow inside the logger's fields and groups
+func (l *FieldLogger) Infow(msg string, keysAndValues ...interface{}) {
+	if l.base.skipped(LevelInfo) {
+		return
+	}
+	l.base.writeMsg(LevelInfo, msg, l.With(kvFields(keysAndValues)...).fields)
+}
+
+//Debugw log.Debugw inside the logger's fields and groups
+func (l *FieldLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	if l.base.skipped(LevelDebug) {
+		return
+	}
+	l.base.writeMsg(LevelDebug, msg, l.With(kvFields(keysAndValues)...).fields)
+}
@@ -0,0 +1,27 @@
+package logg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyValueMethods(t *testing.T) {
+	log := NewLogger(10)
+	r := addRecorder(log, "record")
+	log.Infow("even", "user", "bob", "id", 7)
+	log.Warnw("odd", "user", "bob", "dangling")
+	log.Errorw("non-string key", 42, "answer", nil, "none")
+	log.Debugw("no fields")
+	log.WithGroup("req").Infow("grouped", "id", 1)
+
+	want := []string{
+		"[I] even user=bob id=7",
+		"[W] odd user=bob !BADKEY=dangling",
+		"[E] non-string key 42=answer <nil>=none",
+		"[D] no fields",
+		"[I] grouped req.id=1",
+	}
+	if got := r.Lines(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+package logg
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"strconv"
+)
+
+var pid = os.Getpid()
+
+//goroutineID parse the id of the calling goroutine from the header of its
+//stack trace, like "goroutine 18 [running]:", 0 if it can not be parsed.
+//It costs about a microsecond, so it is only called when an appender asks for it
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+//goroutineTagger is implemented by appenders that need Entry.Goroutine,
+//wrappers forward it from the appenders they write to
+type goroutineTagger interface {
+	includesGoroutine() bool
+}
+
+func wantsGoroutine(out Appender) bool {
+	gt, ok := out.(goroutineTagger)
+	return ok && gt.includesGoroutine()
+}
+
+//tagOptions the "includepid" and "includegoroutine" appender options,
+//written as pid=N goroutine=N fields at the end of the message
+type tagOptions struct {
+	IncludePID       bool `json:"includepid"`
+	IncludeGoroutine bool `json:"includegoroutine"`
+}
+
+func (t tagOptions) includesGoroutine() bool {
+	return t.IncludeGoroutine
+}
+
+//tag return e, or a copy of e carrying the pid and goroutine fields
+func (t tagOptions) tag(e *Entry) *Entry {
+	if !t.IncludePID && !t.IncludeGoroutine {
+		return e
+	}
+	tagged := *e
+	tagged.text = ""
+	tagged.Fields = make([]Field, len(e.Fields), len(e.Fields)+2)
+	copy(tagged.Fields, e.Fields)
+	if t.IncludePID {
+		tagged.Fields = append(tagged.Fields, Field{"pid", pid})
+	}
+	if t.IncludeGoroutine {
+		tagged.Fields = append(tagged.Fields, Field{"goroutine", e.Goroutine})
+	}
+	return &tagged
+}
+
+//tagText append the pid and goroutine fields to msg, the goroutine is the
+//calling one as WriteMsg has no Entry. The loggers and wrappers never call
+//WriteMsg from another goroutine, they pass the Entry captured by the caller
+func (t tagOptions) tagText(msg string) string {
+	if t.IncludePID {
+		msg += " pid=" + strconv.Itoa(pid)
+	}
+	if t.IncludeGoroutine {
+		msg += " goroutine=" + strconv.FormatUint(goroutineID(), 10)
+	}
+	return msg
+}
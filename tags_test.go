@@ -0,0 +1,92 @@
+package logg
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGoroutineID(t *testing.T) {
+	ids := make(chan uint64, 2)
+	go func() { ids <- goroutineID() }()
+	go func() { ids <- goroutineID() }()
+	a, b := <-ids, <-ids
+	if a == 0 || b == 0 || a == b {
+		t.Fatalf("goroutine ids %d and %d", a, b)
+	}
+}
+
+func TestIncludePIDAndGoroutine(t *testing.T) {
+	dir := t.TempDir()
+	log := NewLogger(10)
+	if err := log.SetAppender("file", `{"filename":"`+filepath.Join(dir, "tags.log")+`","includepid":true,"includegoroutine":true}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.SetAppender("file", `{"filename":"`+filepath.Join(dir, "tags.json")+`","format":"json","includegoroutine":true}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.SetAppender("file", `{"filename":"`+filepath.Join(dir, "plain.log")+`"}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Async()
+	done := make(chan uint64)
+	go func() {
+		log.Info("tagged")
+		done <- goroutineID()
+	}()
+	gid := strconv.FormatUint(<-done, 10)
+	log.Close()
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if text, want := read("tags.log"), "[I] tagged pid="+strconv.Itoa(os.Getpid())+" goroutine="+gid+"\n"; !strings.HasSuffix(text, want) {
+		t.Fatalf("text output %q, want suffix %q", text, want)
+	}
+	if text, want := read("tags.json"), `"msg":"tagged","goroutine":`+gid+"}"; !strings.Contains(text, want) {
+		t.Fatalf("json output %q, want %q", text, want)
+	}
+	if text := read("plain.log"); strings.Contains(text, "pid=") || strings.Contains(text, "goroutine=") {
+		t.Fatalf("tags written without the options: %q", text)
+	}
+}
+
+func TestIncludeGoroutineWrapped(t *testing.T) {
+	dir := t.TempDir()
+	file, err := newAppender("file", `{"filename":"`+filepath.Join(dir, "wrapped.log")+`","includegoroutine":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fanout := NewFanoutAppender(10, NewRetryAppender(NewCircuitAppender(file, 1, time.Second), 1, 0))
+	log := NewLogger(10)
+	log.AddAppender("fanout", fanout)
+	log.Async()
+	done := make(chan uint64)
+	go func() {
+		log.Info("logged")
+		fanout.WriteMsg(time.Now(), "[I] written", LevelInfo)
+		done <- goroutineID()
+	}()
+	gid := strconv.FormatUint(<-done, 10)
+	log.Close()
+	data, err := os.ReadFile(filepath.Join(dir, "wrapped.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %q", lines)
+	}
+	for _, line := range lines {
+		if !strings.HasSuffix(line, " goroutine="+gid) {
+			t.Fatalf("%q not tagged with the logging goroutine %s", line, gid)
+		}
+	}
+}
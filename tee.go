@@ -0,0 +1,77 @@
+package logg
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+//teeAppender forward entries to another logger, filtered by its level and
+//written to its appenders
+type teeAppender struct {
+	target *BaseLogger
+}
+
+//NewTeeAppender return an appender forwarding every message to target, like
+//a library logger feeding the application's one. A message never reaches the
+//same logger twice, so loggers teed into each other (or themselves) do not loop
+func NewTeeAppender(target *BaseLogger) Appender {
+	return &teeAppender{target: target}
+}
+
+//Tee return a logger forwarding its messages to loggers, each of them
+//filters and writes them with its own level and appenders
+func Tee(loggers ...*BaseLogger) *BaseLogger {
+	log := NewLogger(defautChannelBuffer)
+	for _, target := range loggers {
+		if target != nil {
+			log.AddAppender("tee", NewTeeAppender(target))
+		}
+	}
+	return log
+}
+
+func (t *teeAppender) Init(config string) error {
+	return nil
+}
+
+func (t *teeAppender) WriteMsg(when time.Time, msg string, level int) error {
+	t.target.forward(&Entry{When: when, Level: level, Msg: msg})
+	return nil
+}
+
+func (t *teeAppender) WriteEntry(e *Entry) error {
+	t.target.forward(e)
+	return nil
+}
+
+//Flush does not flush the target, which would recurse with loggers teed into each other
+func (t *teeAppender) Flush() {
+
+}
+
+//Destroy leave the target open, it belongs to its owner
+func (t *teeAppender) Destroy() {
+
+}
+
+//forward write a copy of src coming from another logger
+func (log *BaseLogger) forward(src *Entry) {
+	if src.Level > log.Level() || src.origin == log {
+		return
+	}
+	for _, seen := range src.via {
+		if seen == log {
+			return
+		}
+	}
+	e := log.logMsgPool.Get().(*Entry)
+	*e = *src
+	e.stamp = nil //points into the stampBuf of src
+	e.via = make([]*BaseLogger, len(src.via), len(src.via)+1)
+	copy(e.via, src.via)
+	e.via = append(e.via, log)
+	if atomic.LoadInt32(&log.paused) == 1 && log.hold(e) {
+		return
+	}
+	log.dispatch(e)
+}
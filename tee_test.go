@@ -0,0 +1,39 @@
+package logg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTee(t *testing.T) {
+	own, host := NewLogger(10), NewLogger(10)
+	host.SetLevel(LevelWarn)
+	ownRec, hostRec := addRecorder(own, "own"), addRecorder(host, "host")
+	lib := Tee(own, host)
+	lib.Info("info")
+	lib.Error("error")
+
+	if got, want := ownRec.Lines(), []string{"[I] info", "[E] error"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("own logger got %q, want %q", got, want)
+	}
+	if got, want := hostRec.Lines(), []string{"[E] error"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("host logger got %q, want %q", got, want)
+	}
+}
+
+func TestTeeLoop(t *testing.T) {
+	a, b := NewLogger(10), NewLogger(10)
+	aRec, bRec := addRecorder(a, "a"), addRecorder(b, "b")
+	a.AddAppender("self", NewTeeAppender(a))
+	a.AddAppender("b", NewTeeAppender(b))
+	b.AddAppender("a", NewTeeAppender(a))
+	a.Info("from a")
+	b.Info("from b")
+
+	if got, want := aRec.Lines(), []string{"[I] from a", "[I] from b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("a got %q, want %q", got, want)
+	}
+	if got, want := bRec.Lines(), []string{"[I] from a", "[I] from b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("b got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package logg
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+//TestLogT the part of testing.TB used by NewTestLogger
+type TestLogT interface {
+	Helper()
+	Log(args ...interface{})
+	Cleanup(fn func())
+}
+
+//testAppender write the text output to the test log
+type testAppender struct {
+	t TestLogT
+}
+
+func (a *testAppender) Init(config string) error { return nil }
+
+func (a *testAppender) WriteMsg(when time.Time, msg string, level int) error {
+	a.t.Helper()
+	a.t.Log(when.Format(TimeLayoutMicro) + " " + msg)
+	return nil
+}
+
+func (a *testAppender) Flush() {}
+
+func (a *testAppender) Destroy() {}
+
+//NewTestLogger return a synchronous logger at LevelDebug writing to t.Log, so
+//the output is shown with the test it belongs to, closed by t.Cleanup
+func NewTestLogger(t TestLogT) *BaseLogger {
+	log := NewLogger(10)
+	log.AddAppender("test", &testAppender{t: t})
+	t.Cleanup(log.Close)
+	return log
+}
+
+//Recorder an appender keeping what is written to it for tests, safe for
+//concurrent use. Add it with AddAppender, the zero Recorder is ready to use
+type Recorder struct {
+	sync.Mutex
+	frozen   time.Time
+	levels   []int
+	msgs     []string
+	rendered strings.Builder
+	lg       *logWriter
+}
+
+//NewRecorder return an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+//FreezeTime render the following messages at t instead of their time, so
+//RenderedLines can be compared to golden output
+func (r *Recorder) FreezeTime(t time.Time) {
+	r.Lock()
+	r.frozen = t
+	r.Unlock()
+}
+
+func (r *Recorder) Init(config string) error { return nil }
+
+func (r *Recorder) WriteMsg(when time.Time, msg string, level int) error {
+	r.Lock()
+	if !r.frozen.IsZero() {
+		when = r.frozen
+	}
+	if r.lg == nil {
+		r.lg = newLogWriter(&r.rendered)
+	}
+	r.levels = append(r.levels, level)
+	r.msgs = append(r.msgs, msg)
+	r.lg.println(when, msg, level)
+	r.Unlock()
+	return nil
+}
+
+func (r *Recorder) Flush() {}
+
+func (r *Recorder) Destroy() {}
+
+//Lines return the text of the messages written, with level label but
+//without timestamp
+func (r *Recorder) Lines() []string {
+	r.Lock()
+	defer r.Unlock()
+	return append([]string(nil), r.msgs...)
+}
+
+//RenderedLines return the messages written as the console appender without
+//color prints them, timestamp included
+func (r *Recorder) RenderedLines() []string {
+	r.Lock()
+	defer r.Unlock()
+	if r.rendered.Len() == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(r.rendered.String(), "\n"), "\n")
+}
@@ -0,0 +1,60 @@
+package logg
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeTB struct {
+	helpers  int
+	logs     []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper() { f.helpers++ }
+
+func (f *fakeTB) Log(args ...interface{}) { f.logs = append(f.logs, fmt.Sprint(args...)) }
+
+func (f *fakeTB) Cleanup(fn func()) { f.cleanups = append(f.cleanups, fn) }
+
+func TestNewTestLogger(t *testing.T) {
+	tb := &fakeTB{}
+	log := NewTestLogger(tb)
+	log.With(Int("id", 1)).Info("to the test log")
+	if len(tb.logs) != 1 || !strings.HasSuffix(tb.logs[0], " [I] to the test log id=1") {
+		t.Fatalf("test log got %q", tb.logs)
+	}
+	if tb.helpers != 1 {
+		t.Fatalf("Helper called %d times for one message", tb.helpers)
+	}
+	if len(tb.cleanups) != 1 {
+		t.Fatalf("%d cleanups registered", len(tb.cleanups))
+	}
+	tb.cleanups[0]()
+	select {
+	case <-log.done:
+	default:
+		t.Fatal("cleanup should close the logger")
+	}
+	NewTestLogger(t).Info("shown with -v")
+}
+
+func TestRecorderFreezeTime(t *testing.T) {
+	log := NewLogger(10)
+	r := NewRecorder()
+	r.FreezeTime(time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local))
+	log.AddAppender("recorder", r)
+	log.Info("first")
+	log.With(Int("id", 7)).Warn("second")
+	log.Close()
+	golden := "2020-01-02 03:04:05.000000[I] first\n" +
+		"2020-01-02 03:04:05.000000[W] second id=7"
+	if got := strings.Join(r.RenderedLines(), "\n"); got != golden {
+		t.Fatalf("got %q, want %q", got, golden)
+	}
+	if got := r.Lines(); len(got) != 2 || got[0] != "[I] first" {
+		t.Fatalf("lines got %q", got)
+	}
+}
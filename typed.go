@@ -0,0 +1,154 @@
+package logg
+
+import "time"
+
+//String field constructor, the typed constructors document the value type
+//and catch typos at compile time, the value is still stored in Field.Value
+func String(key string, val string) Field {
+	return Field{Key: key, Value: val}
+}
+
+//Int field constructor
+func Int(key string, n int) Field {
+	return Field{Key: key, Value: n}
+}
+
+//Int64 field constructor
+func Int64(key string, n int64) Field {
+	return Field{Key: key, Value: n}
+}
+
+//Uint64 field constructor
+func Uint64(key string, n uint64) Field {
+	return Field{Key: key, Value: n}
+}
+
+//Float64 field constructor
+func Float64(key string, f float64) Field {
+	return Field{Key: key, Value: f}
+}
+
+//Bool field constructor
+func Bool(key string, b bool) Field {
+	return Field{Key: key, Value: b}
+}
+
+//Duration field constructor, a number of milliseconds in the structured
+//formats unless "durationunit" is set, like 1.5s in the text
+func Duration(key string, d time.Duration) Field {
+	return Field{Key: key, Value: d}
+}
+
+//Time field constructor, rendered as RFC3339 unless "fieldtimelayout" is set
+func Time(key string, t time.Time) Field {
+	return Field{Key: key, Value: t}
+}
+
+//Err the "error" field holding err, a nil err adds nothing
+func Err(err error) Field {
+	if err == nil {
+		return Group("")
+	}
+	return Field{Key: "error", Value: err}
+}
+
+//Any field constructor for the other types
+func Any(key string, val interface{}) Field {
+	return Field{Key: key, Value: val}
+}
+
+//FatalFields log msg at LevelFatal with fields, like FatalFields("msg", String("k", v))
+func (log *BaseLogger) FatalFields(msg string, fields ...Field) {
+	if log.skipped(LevelFatal) {
+		return
+	}
+	log.writeMsg(LevelFatal, msg, fields)
+}
+
+//ErrorFields log msg at LevelError with fields, like ErrorFields("msg", String("k", v))
+func (log *BaseLogger) ErrorFields(msg string, fields ...Field) {
+	if log.skipped(LevelError) {
+		return
+	}
+	log.writeMsg(LevelError, msg, fields)
+}
+
+//WarnFields log msg at LevelWarn with fields, like WarnFields("msg", String("k", v))
+func (log *BaseLogger) WarnFields(msg string, fields ...Field) {
+	if log.skipped(LevelWarn) {
+		return
+	}
+	log.writeMsg(LevelWarn, msg, fields)
+}
+
+//InfoFields log msg at LevelInfo with fields, like InfoFields("msg", String("k", v))
+func (log *BaseLogger) InfoFields(msg string, fields ...Field) {
+	if log.skipped(LevelInfo) {
+		return
+	}
+	log.writeMsg(LevelInfo, msg, fields)
+}
+
+//DebugFields log msg at LevelDebug with fields, like DebugFields("msg", String("k", v))
+func (log *BaseLogger) DebugFields(msg string, fields ...Field) {
+	if log.skipped(LevelDebug) {
+		return
+	}
+	log.writeMsg(LevelDebug, msg, fields)
+}
+
+//FatalFields log.FatalFields inside the logger's fields and groups
+func (l *FieldLogger) FatalFields(msg string, fields ...Field) {
+	if l.base.skipped(LevelFatal) {
+		return
+	}
+	l.base.writeMsg(LevelFatal, msg, l.With(fields...).fields)
+}
+
+//ErrorFields log.ErrorFields inside the logger's fields and groups
+func (l *FieldLogger) ErrorFields(msg string, fields ...Field) {
+	if l.base.skipped(LevelError) {
+		return
+	}
+	l.base.writeMsg(LevelError, msg, l.With(fields...).fields)
+}
+
+//WarnFields log.WarnFields inside the logger's fields and groups
+func (l *FieldLogger) WarnFields(msg string, fields ...Field) {
+	if l.base.skipped(LevelWarn) {
+		return
+	}
+	l.base.writeMsg(LevelWarn, msg, l.With(fields...).fields)
+}
+
+//InfoFields log.InfoFields inside the logger's fields and groups
+func (l *FieldLogger) InfoFields(msg string, fields ...Field) {
+	if l.base.skipped(LevelInfo) {
+		return
+	}
+	l.base.writeMsg(LevelInfo, msg, l.With(fields...).fields)
+}
+
+//DebugFields log.DebugFields inside the logger's fields and groups
+func (l *FieldLogger) DebugFields(msg string, fields ...Field) {
+	if l.base.skipped(LevelDebug) {
+		return
+	}
+	l.base.writeMsg(LevelDebug, msg, l.With(fields...).fields)
+}
+
+//Event log fields without a message, a pure event whose json has no "msg"
+func (log *BaseLogger) Event(level int, fields ...Field) {
+	if level < LevelFatal || level > LevelDebug || log.skipped(level) {
+		return
+	}
+	log.writeMsg(level, "", fields)
+}
+
+//Event log.Event inside the logger's fields and groups
+func (l *FieldLogger) Event(level int, fields ...Field) {
+	if level < LevelFatal || level > LevelDebug || l.base.skipped(level) {
+		return
+	}
+	l.base.writeMsg(level, "", l.With(fields...).fields)
+}
@@ -0,0 +1,64 @@
+package logg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTypedFields(t *testing.T) {
+	log := NewLogger(10)
+	r := addRecorder(log, "record")
+	when := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	log.InfoFields("typed", String("s", "v"), Int("i", -1), Int64("i64", 1<<40), Uint64("u", 7),
+		Float64("f", 1.5), Bool("b", true), Duration("d", 2*time.Second), Time("t", when),
+		Err(errors.New("boom")), Err(nil), Any("a", []int{1}))
+	log.WithGroup("req").WarnFields("grouped", Int("id", 3))
+	log.SetLevel(LevelInfo)
+	log.DebugFields("filtered", Int("id", 4))
+	want := []string{
+		"[I] typed s=v i=-1 i64=1099511627776 u=7 f=1.5 b=true d=2s t=2020-01-02T03:04:05Z error=boom a=[1]",
+		"[W] grouped req.id=3",
+	}
+	lines := r.Lines()
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got %q\nwant %q", lines, want)
+	}
+	got := formatJSON(log.With(Int("n", 1), Bool("ok", false), Err(nil)))
+	if !strings.HasSuffix(got, `"msg":"hello","n":1,"ok":false}`) {
+		t.Fatalf("json got %s", got)
+	}
+}
+
+func TestEvent(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "events.log")
+	log := NewLogger(10)
+	if err := log.SetAppender("file", `{"filename":"`+filename+`","format":"json"}`); err != nil {
+		t.Fatal(err)
+	}
+	log.Event(LevelInfo, String("event", "signup"), Int("user", 7))
+	log.WithGroup("req").Event(LevelWarn, Int("status", 503))
+	log.SetLevel(LevelInfo)
+	log.Event(LevelDebug, String("event", "filtered"))
+	log.SetLevel(LevelDebug + 1)
+	log.Event(LevelDebug+1, String("event", "out of range"))
+	log.With(Int("n", 1)).Event(LevelFatal-1, String("event", "out of range"))
+	log.Close()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %q", lines)
+	}
+	if !strings.HasSuffix(lines[0], `"level":"info","event":"signup","user":7}`) || strings.Contains(lines[0], `"msg"`) {
+		t.Fatalf("event got %s", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], `"level":"warn","req":{"status":503}}`) {
+		t.Fatalf("grouped event got %s", lines[1])
+	}
+}